@@ -0,0 +1,174 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	reAsciidocTitle     = regexp.MustCompile(`^(={1,6})\s+(\S.*?)\s*$`)
+	reAsciidocAnchor    = regexp.MustCompile(`^\[\[([^\],]+)(,[^\]]*)?\]\]$|^\[#([^\].,%\s]+)[^\]]*\]$`)
+	reAsciidocDelimiter = regexp.MustCompile(`^(-{4,}|\.{4,}|\+{4,}|/{4,}|_{4,}|\*{4,}|={4,}|` + "`{3}" + `)$`)
+	// reAsciidocInvalidID matches the characters removed from the
+	// generated section ids.
+	reAsciidocInvalidID = regexp.MustCompile(`&(?:[a-z][a-z]+\d{0,2}|#\d\d\d{0,4}|#x[\da-f][\da-f][\da-f]{0,3});|[^ \p{L}\p{M}\p{Nd}\p{Pc}\-.]+`)
+)
+
+// asciidocFormat is AsciiDoc document format. The table of contents links
+// the sections with cross references, e.g. <<_install,Install>>.
+type asciidocFormat struct{}
+
+func (asciidocFormat) markers() [][]string {
+	return [][]string{{"// begin-toc", "// end-toc"}}
+}
+
+func (asciidocFormat) ignoreMarker() string {
+	return "// toc-ignore"
+}
+
+func (asciidocFormat) ignoreMarkerSkipsBlank() bool {
+	return true
+}
+
+func (asciidocFormat) slugger() Slugger {
+	return &asciidocSlugger{ids: make(map[string]bool)}
+}
+
+func (asciidocFormat) hasTitleAnchor() bool {
+	return false
+}
+
+func (asciidocFormat) parse(lines []string) *parsedDocument {
+	return parseAsciidoc(lines)
+}
+
+func (asciidocFormat) renderTitle(title string, level int) []string {
+	// The block title is attached to the list.
+	return []string{"." + title}
+}
+
+func (asciidocFormat) renderEntries(entries []*tocEntry, minDepth int, sep string, ordered bool) []string {
+	// Only the asterisks and dots nest.
+	sep = "*"
+	if ordered {
+		sep = "."
+	}
+	var lines []string
+	for _, h := range entries {
+		// The nesting level of a list item is the number of markers.
+		marker := strings.Repeat(sep, h.depth-minDepth+1)
+		lines = append(lines, marker+" <<"+strings.TrimPrefix(h.link, "#")+","+h.title+">>")
+	}
+	return append(lines, "")
+}
+
+func (asciidocFormat) setHeadingNumber(lines []string, h *parsedHeading, number string) {
+	m := reAsciidocTitle.FindStringSubmatch(strings.TrimRight(lines[h.text], " \t"))
+	if m == nil {
+		return
+	}
+	lines[h.text] = m[1] + " " + number + " " + stripSectionNumber(m[2])
+}
+
+// parseAsciidoc scans the lines of an AsciiDoc document for section titles,
+// e.g. "== Install". The lines of delimited blocks, e.g. listing blocks,
+// and the discrete headings are skipped. The explicit ids of the sections,
+// e.g. [[install]] or [#install], are preserved.
+func parseAsciidoc(lines []string) *parsedDocument {
+	doc := &parsedDocument{
+		lines:   lines,
+		literal: make([]bool, len(lines)),
+	}
+	var delimiter string
+	for i, line := range lines {
+		line = strings.TrimRight(line, " \t")
+		if delimiter != "" {
+			doc.literal[i] = true
+			if line == delimiter {
+				delimiter = ""
+			}
+			continue
+		}
+		if reAsciidocDelimiter.MatchString(line) {
+			delimiter = line
+			doc.literal[i] = true
+			continue
+		}
+		m := reAsciidocTitle.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		h := &parsedHeading{
+			level: len(m[1]),
+			title: m[2],
+			line:  i,
+			text:  i,
+		}
+		isDiscrete := false
+		// The block attribute lines precede the section title.
+		for j := i - 1; j >= 0; j-- {
+			s := strings.TrimSpace(lines[j])
+			if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+				break
+			}
+			if a := reAsciidocAnchor.FindStringSubmatch(s); a != nil {
+				h.id = a[1] + a[3]
+			}
+			if s == "[discrete]" || s == "[float]" || strings.HasPrefix(s, "[discrete") {
+				isDiscrete = true
+			}
+			h.line = j
+		}
+		if isDiscrete {
+			continue
+		}
+		doc.headings = append(doc.headings, h)
+	}
+	return doc
+}
+
+// asciidocSlugger follows Asciidoctor's generated section ids with the
+// default "_" prefix and separator.
+type asciidocSlugger struct {
+	ids map[string]bool
+}
+
+func (a *asciidocSlugger) Slug(s string) string {
+	s = reAsciidocInvalidID.ReplaceAllString(strings.ToLower(s), "")
+	var sb strings.Builder
+	sb.WriteString("_")
+	translated := false
+	for _, c := range s {
+		if c == ' ' || c == '.' || c == '-' {
+			if !translated {
+				sb.WriteRune('_')
+			}
+			translated = true
+			continue
+		}
+		translated = false
+		sb.WriteRune(c)
+	}
+	id := strings.TrimSuffix(sb.String(), "_")
+	slug := id
+	for i := 2; a.ids[slug]; i++ {
+		slug = id + "_" + strconv.Itoa(i)
+	}
+	a.ids[slug] = true
+	return slug
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"regexp"
+	"runtime/debug"
+	"strings"
+)
+
+// readBuildInfo returns the build information embedded in the binary. It
+// is a variable for testing.
+var readBuildInfo = debug.ReadBuildInfo
+
+// pseudoVersionRegex matches the pseudo-versions, e.g.
+// v0.0.0-20200512100000-0c85fbc6d3b2, and the versions of the builds with
+// modified working tree, e.g. v1.0.37+dirty.
+var pseudoVersionRegex = regexp.MustCompile(`(^|[-.])\d{14}-[0-9a-f]{12}|\+dirty$`)
+
+// FromBuildInfo sets the attributes of PackageManager from the build
+// information embedded in the binary by the Go toolchain, e.g. when the
+// binary is built with "go install" without -ldflags. It sets the module
+// path, the module version, unless it is a pseudo-version, the git
+// commit, the commit date, the dirty flag, the build date, i.e. the commit
+// time, the target OS and architecture, the Go version, and the CGO
+// status. The commit is the abbreviated hash. The modified working tree
+// sets the dirty flag, not the "-dirty" suffix of the commit.
+//
+// The attributes set with -ldflags, i.e. the non-default values of the
+// Set* calls, are kept. Therefore, FromBuildInfo is called after the
+// Set* calls.
+func (p *PackageManager) FromBuildInfo() {
+	info, ok := readBuildInfo()
+	if !ok || info == nil {
+		return
+	}
+	p.Module = info.Main.Path
+	v := info.Main.Version
+	if v != "" && v != "(devel)" && !pseudoVersionRegex.MatchString(v) && !p.linked["version"] {
+		p.Version = strings.TrimPrefix(v, "v")
+	}
+
+	settings := make(map[string]string)
+	for _, s := range info.Settings {
+		settings[s.Key] = s.Value
+	}
+	if commit := settings["vcs.revision"]; commit != "" && !p.linked["commit"] {
+		if len(commit) > 7 {
+			commit = commit[:7]
+		}
+		p.Git.Commit = commit
+	}
+	if date := settings["vcs.time"]; date != "" {
+		if !p.linked["date"] {
+			p.Build.Date = date
+		}
+		if !p.linked["commit_date"] {
+			p.Git.CommitDate = date
+		}
+	}
+	if modified := settings["vcs.modified"]; modified != "" && !p.linked["dirty"] {
+		p.Git.Dirty = modified == "true"
+	}
+	if info.GoVersion != "" && !p.linked["go_version"] {
+		p.Build.GoVersion = info.GoVersion
+	}
+	if cgo := settings["CGO_ENABLED"]; cgo != "" && !p.linked["cgo"] {
+		p.Build.CGO = cgo == "1"
+	}
+	if settings["GOOS"] != "" {
+		p.Build.OperatingSystem = settings["GOOS"]
+	}
+	if settings["GOARCH"] != "" {
+		p.Build.Architecture = settings["GOARCH"]
+	}
+}
+
+// DependenciesFromBuildInfo sets the dependencies of PackageManager to the
+// modules the binary is built with.
+func (p *PackageManager) DependenciesFromBuildInfo() {
+	info, ok := readBuildInfo()
+	if !ok || info == nil {
+		return
+	}
+	p.Dependencies = nil
+	for _, m := range info.Deps {
+		dep := &moduleDependency{
+			Path:    m.Path,
+			Version: m.Version,
+		}
+		if m.Replace != nil {
+			dep.Replace = m.Replace.Path
+			if m.Replace.Version != "" {
+				dep.Replace += "@" + m.Replace.Version
+			}
+		}
+		p.Dependencies = append(p.Dependencies, dep)
+	}
+}
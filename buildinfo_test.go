@@ -0,0 +1,151 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+func TestFromBuildInfo(t *testing.T) {
+	defer func() { readBuildInfo = debug.ReadBuildInfo }()
+
+	info := &debug.BuildInfo{
+		GoVersion: "go1.22.1",
+		Main: debug.Module{
+			Path:    "github.com/greenpau/versioned",
+			Version: "v1.0.37",
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "CGO_ENABLED", Value: "1"},
+			{Key: "GOOS", Value: "linux"},
+			{Key: "GOARCH", Value: "arm64"},
+			{Key: "vcs.revision", Value: "0c85fbc6d3b2f0b8b5b0e8f6d0b0c7a1c1e2f3a4"},
+			{Key: "vcs.time", Value: "2020-05-12T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	for i, test := range []struct {
+		info    *debug.BuildInfo
+		version string
+		commit  string
+		date    string
+		dirty   string
+		want    PackageManager
+	}{
+		{
+			// The defaults are replaced.
+			info: info,
+			want: PackageManager{
+				Name:    "versioned",
+				Module:  "github.com/greenpau/versioned",
+				Version: "1.0.37",
+				Git:     gitMetadata{Branch: "main", Commit: "0c85fbc", CommitDate: "2020-05-12T10:00:00Z", Dirty: true},
+				Build:   buildMetadata{OperatingSystem: "linux", Architecture: "arm64", Date: "2020-05-12T10:00:00Z", GoVersion: "go1.22.1", CGO: true},
+			},
+		},
+		{
+			// The values set with -ldflags win.
+			info:    info,
+			version: "1.0.38",
+			commit:  "v1.0.38-1-gabcdef0",
+			date:    "2020-06-01",
+			dirty:   "false",
+			want: PackageManager{
+				Name:    "versioned",
+				Module:  "github.com/greenpau/versioned",
+				Version: "1.0.38",
+				Git:     gitMetadata{Branch: "main", Commit: "v1.0.38-1-gabcdef0", CommitDate: "2020-05-12T10:00:00Z"},
+				Build:   buildMetadata{OperatingSystem: "linux", Architecture: "arm64", Date: "2020-06-01", GoVersion: "go1.22.1", CGO: true},
+			},
+		},
+		{
+			// The pseudo-version of the local build is skipped.
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "github.com/greenpau/versioned", Version: "v0.0.0-20200512100000-0c85fbc6d3b2+dirty"},
+			},
+			want: PackageManager{
+				Name:    "versioned",
+				Module:  "github.com/greenpau/versioned",
+				Version: "1.0.0",
+				Git:     gitMetadata{Branch: "main", Commit: "v1.0.0"},
+				Build:   buildMetadata{Date: "2020-01-01"},
+			},
+		},
+		{
+			// The development build has no version and vcs settings.
+			info: &debug.BuildInfo{
+				Main: debug.Module{Path: "github.com/greenpau/versioned", Version: "(devel)"},
+			},
+			want: PackageManager{
+				Name:    "versioned",
+				Module:  "github.com/greenpau/versioned",
+				Version: "1.0.0",
+				Git:     gitMetadata{Branch: "main", Commit: "v1.0.0"},
+				Build:   buildMetadata{Date: "2020-01-01"},
+			},
+		},
+		{
+			// The build information is unavailable.
+			want: PackageManager{
+				Name:    "versioned",
+				Version: "1.0.0",
+				Git:     gitMetadata{Branch: "main", Commit: "v1.0.0"},
+				Build:   buildMetadata{Date: "2020-01-01"},
+			},
+		},
+	} {
+		readBuildInfo = func() (*debug.BuildInfo, bool) {
+			return test.info, test.info != nil
+		}
+		p := NewPackageManager("versioned")
+		p.SetVersion(test.version, "1.0.0")
+		p.SetGitBranch("", "main")
+		p.SetGitCommit(test.commit, "v1.0.0")
+		p.SetBuildDate(test.date, "2020-01-01")
+		p.SetGitDirty(test.dirty, "")
+		p.FromBuildInfo()
+		p.ToolsVersion = ""
+		p.linked = nil
+		if !reflect.DeepEqual(*p, test.want) {
+			t.Fatalf("FAIL: test %d: package mismatch:\n%+v (actual)\n%+v (expected)", i, *p, test.want)
+		}
+	}
+}
+
+func TestDependenciesFromBuildInfo(t *testing.T) {
+	defer func() { readBuildInfo = debug.ReadBuildInfo }()
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Deps: []*debug.Module{
+				{Path: "example.com/a", Version: "v1.0.0"},
+				{Path: "example.com/b", Version: "v0.1.0", Replace: &debug.Module{Path: "../b"}},
+				{Path: "example.com/c", Version: "v0.2.0", Replace: &debug.Module{Path: "example.com/d", Version: "v0.3.0"}},
+			},
+		}, true
+	}
+	p := NewPackageManager("versioned")
+	p.DependenciesFromBuildInfo()
+	want := []*moduleDependency{
+		{Path: "example.com/a", Version: "v1.0.0"},
+		{Path: "example.com/b", Version: "v0.1.0", Replace: "../b"},
+		{Path: "example.com/c", Version: "v0.2.0", Replace: "example.com/d@v0.3.0"},
+	}
+	if !reflect.DeepEqual(p.Dependencies, want) {
+		t.Fatalf("FAIL: dependencies mismatch:\n%+v (actual)\n%+v (expected)", p.Dependencies, want)
+	}
+}
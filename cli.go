@@ -0,0 +1,131 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// FlagRegistrar registers the flags. It is implemented by *flag.FlagSet of
+// the standard library and by *pflag.FlagSet of github.com/spf13/pflag,
+// used by cobra.
+type FlagRegistrar interface {
+	BoolVar(p *bool, name string, value bool, usage string)
+	StringVar(p *string, name string, value string, usage string)
+}
+
+// VersionTemplateSetter sets the template of the version information, e.g.
+// *cobra.Command of github.com/spf13/cobra.
+type VersionTemplateSetter interface {
+	SetVersionTemplate(s string)
+}
+
+// VersionFlag is the version flag registered with AddVersionFlag.
+type VersionFlag struct {
+	pkg    *PackageManager
+	show   bool
+	format string
+}
+
+// WriteVersion writes the version information in the format, i.e. text,
+// the Banner, short, the ShortBanner, json, or yaml.
+func (p *PackageManager) WriteVersion(w io.Writer, format string) error {
+	var b []byte
+	switch format {
+	case "", "text":
+		b = []byte(p.Banner() + "\n")
+	case "short":
+		b = []byte(p.ShortBanner() + "\n")
+	default:
+		var err error
+		b, err = Encode(p, format)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+// AddVersionFlag registers the version flag, i.e. -version, and the format
+// flag, i.e. -version-format, of the version information. The flags are
+// handled with VersionFlag.Handle after the flags are parsed.
+func (p *PackageManager) AddVersionFlag(fs FlagRegistrar) *VersionFlag {
+	f := &VersionFlag{pkg: p}
+	fs.BoolVar(&f.show, "version", false, "print version information and exit")
+	fs.StringVar(&f.format, "version-format", "text", "version information format, i.e. text, short, json, or yaml")
+	return f
+}
+
+// Handle writes the version information and returns true when the version
+// flag is set. Then, the program exits.
+func (f *VersionFlag) Handle(w io.Writer) (bool, error) {
+	if !f.show {
+		return false, nil
+	}
+	return true, f.pkg.WriteVersion(w, f.format)
+}
+
+// RunVersionCommand runs the version subcommand with the arguments
+// following it, e.g. "-format json" in "myapp version -format json", and
+// writes the version information.
+func (p *PackageManager) RunVersionCommand(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet(p.Name+" version", flag.ContinueOnError)
+	fs.SetOutput(w)
+	format := fs.String("format", "text", "version information format, i.e. text, short, json, or yaml")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("version command has unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return p.WriteVersion(w, *format)
+}
+
+// SetUsage sets the usage of the flag set to the one with the short
+// banner, the description, the flags, and the documentation.
+func (p *PackageManager) SetUsage(fs *flag.FlagSet) {
+	fs.Usage = func() {
+		w := fs.Output()
+		fmt.Fprintf(w, "\n%s", p.ShortBanner())
+		if p.Description != "" {
+			fmt.Fprintf(w, " - %s", p.Description)
+		}
+		fmt.Fprintf(w, "\n\nUsage of %s:\n", fs.Name())
+		fs.PrintDefaults()
+		if p.Documentation != "" {
+			fmt.Fprintf(w, "\nDocumentation: %s\n", p.Documentation)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// SetVersionTemplate sets the template of the version information, e.g.
+// the one printed by the --version flag of cobra, to the version
+// information in the format, see WriteVersion.
+func (p *PackageManager) SetVersionTemplate(s VersionTemplateSetter, format string) error {
+	var sb strings.Builder
+	if err := p.WriteVersion(&sb, format); err != nil {
+		return err
+	}
+	// The text is a string constant of the template, because it may have
+	// the template actions, e.g. "{{".
+	s.SetVersionTemplate("{{" + strconv.Quote(sb.String()) + "}}")
+	return nil
+}
@@ -0,0 +1,143 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"bytes"
+	"flag"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+var _ FlagRegistrar = (*flag.FlagSet)(nil)
+
+// testTemplateSetter stores the version template, the same as cobra.
+type testTemplateSetter struct {
+	tmpl string
+}
+
+func (s *testTemplateSetter) SetVersionTemplate(tmpl string) {
+	s.tmpl = tmpl
+}
+
+func TestWriteVersion(t *testing.T) {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.0.0"
+	pkg.Git.Commit = "{{v1.0.0}}"
+
+	for i, test := range []struct {
+		format    string
+		output    string
+		shouldErr bool
+	}{
+		{format: "", output: "myapp 1.0.0, commit: {{v1.0.0}}\n"},
+		{format: "short", output: "myapp 1.0.0\n"},
+		{format: "yaml", output: "name: myapp\n"},
+		{format: "json", output: "{\n  \"name\": \"myapp\",\n"},
+		{format: "toml", shouldErr: true},
+	} {
+		var b bytes.Buffer
+		err := pkg.WriteVersion(&b, test.format)
+		if test.shouldErr {
+			if err == nil {
+				t.Fatalf("FAIL: test %d: expected error, but succeeded", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if !strings.HasPrefix(b.String(), test.output) {
+			t.Fatalf("FAIL: test %d: output mismatch:\n%s (actual)\n%s (expected)", i, b.String(), test.output)
+		}
+
+		// The template of cobra renders the same output.
+		s := &testTemplateSetter{}
+		if err := pkg.SetVersionTemplate(s, test.format); err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		var rendered bytes.Buffer
+		if err := template.Must(template.New("").Parse(s.tmpl)).Execute(&rendered, nil); err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if rendered.String() != b.String() {
+			t.Fatalf("FAIL: test %d: template mismatch:\n%s (actual)\n%s (expected)", i, rendered.String(), b.String())
+		}
+	}
+}
+
+func TestAddVersionFlag(t *testing.T) {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.0.0"
+
+	for i, test := range []struct {
+		args   []string
+		show   bool
+		output string
+	}{
+		{args: []string{"-debug"}},
+		{args: []string{"-version"}, show: true, output: "myapp 1.0.0\n"},
+		{args: []string{"-version", "-version-format", "short"}, show: true, output: "myapp 1.0.0\n"},
+		{args: []string{"-version-format=yaml", "-version"}, show: true, output: "name: myapp\n"},
+	} {
+		fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+		fs.Bool("debug", false, "debug")
+		f := pkg.AddVersionFlag(fs)
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		var b bytes.Buffer
+		show, err := f.Handle(&b)
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if show != test.show || !strings.HasPrefix(b.String(), test.output) {
+			t.Fatalf("FAIL: test %d: %t %q (actual) vs. %t %q (expected)", i, show, b.String(), test.show, test.output)
+		}
+	}
+}
+
+func TestRunVersionCommand(t *testing.T) {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.0.0"
+	var b bytes.Buffer
+	if err := pkg.RunVersionCommand([]string{"-format", "short"}, &b); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if b.String() != "myapp 1.0.0\n" {
+		t.Fatalf("FAIL: output mismatch: %q", b.String())
+	}
+	if err := pkg.RunVersionCommand([]string{"extra"}, &b); err == nil {
+		t.Fatalf("FAIL: expected error, but succeeded")
+	}
+}
+
+func TestSetUsage(t *testing.T) {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.0.0"
+	pkg.Description = "My app"
+	pkg.Documentation = "https://example.com/myapp"
+	var b bytes.Buffer
+	fs := flag.NewFlagSet("myapp", flag.ContinueOnError)
+	fs.SetOutput(&b)
+	fs.Bool("debug", false, "enable debugging")
+	pkg.SetUsage(fs)
+	fs.Usage()
+	expected := "\nmyapp 1.0.0 - My app\n\nUsage of myapp:\n  -debug\n    \tenable debugging\n\nDocumentation: https://example.com/myapp\n\n"
+	if b.String() != expected {
+		t.Fatalf("FAIL: usage mismatch:\n%q (actual)\n%q (expected)", b.String(), expected)
+	}
+}
@@ -0,0 +1,678 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/greenpau/versioned"
+)
+
+// tocOptions are the flags of the table of contents updates.
+type tocOptions struct {
+	beginMarker     string
+	endMarker       string
+	title           string
+	titleLevel      int
+	noTitle         bool
+	minLevel        int
+	maxLevel        int
+	slugStyle       string
+	format          string
+	listStyle       string
+	numberHeadings  bool
+	lenient         bool
+	excludePatterns stringList
+}
+
+// addFlags adds the flags, whose names start with the prefix, e.g. "toc-".
+func (o *tocOptions) addFlags(fs *flag.FlagSet, prefix string) {
+	fs.StringVar(&o.beginMarker, prefix+"begin-marker", "<!-- begin-markdown-toc -->", "table of contents begin marker")
+	fs.StringVar(&o.endMarker, prefix+"end-marker", "<!-- end-markdown-toc -->", "table of contents end marker")
+	fs.StringVar(&o.title, prefix+"title", "Table of Contents", "table of contents title")
+	fs.IntVar(&o.titleLevel, prefix+"title-level", 2, "table of contents title heading level")
+	fs.BoolVar(&o.noTitle, prefix+"no-title", false, "omit table of contents title")
+	fs.IntVar(&o.minLevel, prefix+"min-level", 2, "minimum heading level included in table of contents")
+	fs.IntVar(&o.maxLevel, prefix+"max-level", 6, "maximum heading level included in table of contents")
+	fs.StringVar(&o.slugStyle, prefix+"slug", "github", "heading anchor style of table of contents links, i.e. github, gitlab, bitbucket, or hugo")
+	fs.StringVar(&o.listStyle, prefix+"list-style", "*", "table of contents list style, i.e. *, -, +, ordered, or numbered")
+	fs.BoolVar(&o.numberHeadings, prefix+"number-headings", false, "write section numbers to headings")
+	fs.BoolVar(&o.lenient, prefix+"lenient", false, "normalize headings skipping levels and print warnings instead of failing")
+	fs.Var(&o.excludePatterns, prefix+"exclude", "exclude headings matching `REGEX` from table of contents, repeatable")
+}
+
+// newTableOfContents returns the table of contents configured with the
+// flags.
+func (o *tocOptions) newTableOfContents() (*versioned.TableOfContents, error) {
+	toc := versioned.NewTableOfContents()
+	if err := toc.AddMarkers(o.beginMarker, o.endMarker); err != nil {
+		return nil, err
+	}
+	toc.AddTitle(o.title)
+	if err := toc.AddTitleLevel(o.titleLevel); err != nil {
+		return nil, err
+	}
+	if o.noTitle {
+		toc.DisableTitle()
+	}
+	if err := toc.AddLevels(o.minLevel, o.maxLevel); err != nil {
+		return nil, err
+	}
+	for _, s := range o.excludePatterns {
+		if err := toc.AddExcludePattern(s); err != nil {
+			return nil, err
+		}
+	}
+	if err := toc.AddSlugStyle(o.slugStyle); err != nil {
+		return nil, err
+	}
+	if err := toc.AddListStyle(o.listStyle); err != nil {
+		return nil, err
+	}
+	if o.numberHeadings {
+		toc.EnableHeadingNumbers()
+	}
+	if o.lenient {
+		toc.EnableLenientMode()
+	}
+	return toc, nil
+}
+
+// docsOptions are the flags of the documentation directory updates.
+type docsOptions struct {
+	dir       string
+	indexFile string
+	title     string
+	order     string
+}
+
+// licenseOptions are the flags of the license commands.
+type licenseOptions struct {
+	filePath         string
+	licenseType      string
+	copyrightHolders stringList
+	authors          string
+	year             uint64
+	// sourcePaths are the paths of the source files with the license
+	// headers checked against the license file.
+	sourcePaths stringList
+}
+
+// addFlags adds the flags of the license header, with the license type
+// flag of the provided name.
+func (o *licenseOptions) addFlags(fs *flag.FlagSet, typeFlag string) {
+	fs.StringVar(&o.licenseType, typeFlag, "apache", "license type")
+	fs.Var(&o.copyrightHolders, "copyright", "license copyright holder, repeat for multiple holders")
+	fs.StringVar(&o.authors, "authors", "", "add \"The `PROJECT` Authors\" copyright holder referring to AUTHORS file")
+	fs.Uint64Var(&o.year, "year", 0, "copyright year")
+}
+
+// depsOptions are the flags of the dependency license report.
+type depsOptions struct {
+	filePath       string
+	reportFormat   string
+	deniedLicenses string
+}
+
+// bumpOptions are the flags of the version increments.
+type bumpOptions struct {
+	versionFile string
+	major       bool
+	minor       bool
+	patch       bool
+	factor      uint64
+	silent      bool
+	// generateGo are the Go files generated with "sync -generate go",
+	// regenerated with the new version.
+	generateGo stringList
+}
+
+// syncOptions are the flags of the version synchronization.
+type syncOptions struct {
+	versionFile string
+	filePath    string
+	format      string
+	pattern     string
+	template    string
+	goVar       string
+	preRelease  bool
+	release     bool
+	// The build user and date, and the description and documentation of
+	// the package synchronized to Go files.
+	buildUser     string
+	buildDate     string
+	description   string
+	documentation string
+	// The format of the generated file, e.g. go, and the package of the
+	// generated Go file.
+	generate  string
+	goPackage string
+}
+
+// ldflagsOptions are the flags of the linker flags command.
+type ldflagsOptions struct {
+	versionFile string
+	pkgPath     string
+	vars        stringList
+	buildDate   string
+	extra       string
+	build       bool
+	buildArgs   []string
+}
+
+// ldflagsKeys are the keys of the variables of the linker flags, matching
+// the JSON names of the package metadata.
+var ldflagsKeys = []string{
+	"version", "git_branch", "git_commit", "git_tag", "git_commit_date", "git_dirty",
+	"build_user", "build_date", "build_host", "build_go_version", "build_cgo",
+}
+
+// getVariables returns the names of the variables, i.e. the defaults
+// overridden with the -var flags.
+func (o *ldflagsOptions) getVariables() (*versioned.LdflagsVariables, error) {
+	vars := versioned.NewLdflagsVariables()
+	names := map[string]*string{
+		"version":          &vars.Version,
+		"git_branch":       &vars.GitBranch,
+		"git_commit":       &vars.GitCommit,
+		"git_tag":          &vars.GitTag,
+		"git_commit_date":  &vars.GitCommitDate,
+		"git_dirty":        &vars.GitDirty,
+		"build_user":       &vars.BuildUser,
+		"build_date":       &vars.BuildDate,
+		"build_host":       &vars.BuildHost,
+		"build_go_version": &vars.BuildGoVersion,
+		"build_cgo":        &vars.BuildCGO,
+	}
+	for _, v := range o.vars {
+		kv := strings.SplitN(v, "=", 2)
+		name, exists := names[kv[0]]
+		if !exists || len(kv) != 2 {
+			return nil, fmt.Errorf("ldflags variable %q is invalid, expected KEY=NAME with keys: %s", v, strings.Join(ldflagsKeys, ", "))
+		}
+		*name = kv[1]
+	}
+	return vars, nil
+}
+
+// buildDateFormat is the format of the build date, matching the one of
+// the Makefile.
+const buildDateFormat = "2006-01-02"
+
+// getBuildDate returns the build date. The "now" is the current date and
+// the "source" is the date of the source code in the directory, see
+// versioned.SourceDate.
+func getBuildDate(date, dir string) (string, error) {
+	switch date {
+	case "now":
+		return time.Now().UTC().Format(buildDateFormat), nil
+	case "source":
+		d, err := versioned.SourceDate(dir)
+		if err != nil {
+			return "", err
+		}
+		return d.Format(buildDateFormat), nil
+	}
+	return date, nil
+}
+
+func initVersion(versionFile string) error {
+	result.Command = "init"
+	if version, err := versioned.NewVersionFromFile(versionFile); err == nil {
+		if !isStructuredOutput() {
+			fmt.Fprintf(os.Stderr, "version file already exists, version: %s\n", version)
+		}
+		result.Version = version.String()
+		return nil
+	}
+	snapshot.add(versionFile)
+	version, _ := versioned.NewVersion("1.0.0")
+	if err := version.SetFile(versionFile); err != nil {
+		return fmt.Errorf("Failed to initialize version file: %s", err)
+	}
+	if err := version.UpdateFile(); err != nil {
+		return fmt.Errorf("Failed to initialize new version file: %s", err)
+	}
+	result.Version = version.String()
+	return nil
+}
+
+func showVersion(versionFile string) error {
+	result.Command = "show"
+	version, err := versioned.NewVersionFromFile(versionFile)
+	if err != nil {
+		return err
+	}
+	result.Version = version.String()
+	if !isStructuredOutput() {
+		fmt.Fprintf(os.Stdout, "%s\n", version)
+	}
+	return nil
+}
+
+func bumpVersion(o *bumpOptions) error {
+	result.Command = "bump"
+	version, err := versioned.NewVersionFromFile(o.versionFile)
+	if err != nil {
+		return err
+	}
+	snapshot.add(o.versionFile)
+	oldVersion := *version
+	silent := o.silent || isStructuredOutput()
+
+	if o.major {
+		version.IncrementMajor(o.factor)
+		if !silent {
+			fmt.Fprintf(os.Stderr, "increased major version by %d, current version: %s\n",
+				o.factor, version,
+			)
+		}
+	}
+
+	if o.minor {
+		version.IncrementMinor(o.factor)
+		if !silent {
+			fmt.Fprintf(os.Stderr, "increased minor version by %d, current version: %s\n",
+				o.factor, version,
+			)
+		}
+	}
+
+	if o.patch {
+		version.IncrementPatch(o.factor)
+		if !silent {
+			fmt.Fprintf(os.Stderr, "increased patch version by %d, current version: %s\n",
+				o.factor, version,
+			)
+		}
+	}
+
+	result.OldVersion = oldVersion.String()
+	result.NewVersion = version.String()
+	if err := version.UpdateFile(); err != nil {
+		return err
+	}
+
+	if !silent {
+		fmt.Fprintf(os.Stderr, "updated version: %s, previous version: %s\n",
+			version, &oldVersion,
+		)
+	}
+
+	for _, fp := range o.generateGo {
+		snapshot.add(fp)
+		pkg := versioned.NewPackageManager("")
+		pkg.Version = version.String()
+		// The git and build metadata are not gathered, and the ones of the
+		// file are kept.
+		if _, err := versioned.GenerateFile(fp, pkg, "go", &versioned.GenerateOptions{
+			KeepMetadata: true,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func syncVersion(o *syncOptions) error {
+	if result.Command == "" {
+		result.Command = "sync"
+	}
+	version, err := versioned.NewVersionFromFile(o.versionFile)
+	if err != nil {
+		return err
+	}
+	// The generated file is created when it does not exist.
+	fi, err := os.Stat(o.filePath)
+	switch {
+	case err == nil:
+		if !fi.Mode().IsRegular() {
+			return fmt.Errorf("path %s is not a file", o.filePath)
+		}
+	case o.generate == "" || !os.IsNotExist(err):
+		return err
+	}
+	snapshot.add(o.filePath)
+
+	commit, err := executeShell([]string{"git", "describe", "--always"})
+	if err != nil {
+		return err
+	}
+	branch, err := executeShell([]string{"git", "rev-parse", "--abbrev-ref", "HEAD", "--"})
+	if err != nil {
+		return err
+	}
+
+	if o.release {
+		branch = ""
+		commit = ""
+	}
+
+	pkg := versioned.NewPackageManager("")
+	pkg.Version = version.String()
+	pkg.Git.Branch = branch
+	pkg.Git.Commit = commit
+	pkg.Build.User = o.buildUser
+	pkg.Description = o.description
+	pkg.Documentation = o.documentation
+	pkg.Build.Date, err = getBuildDate(o.buildDate, filepath.Dir(o.filePath))
+	if err != nil {
+		return err
+	}
+	result.Version = pkg.Version
+
+	opts := &versioned.SyncOptions{
+		PreRelease: o.preRelease,
+		GoVar:      o.goVar,
+	}
+	if o.generate != "" {
+		if o.pattern != "" || o.format != "" || o.goVar != "" {
+			return fmt.Errorf("sync generate, pattern, format, and go-var are mutually exclusive")
+		}
+		_, err = versioned.GenerateFile(o.filePath, pkg, o.generate, &versioned.GenerateOptions{
+			Package: o.goPackage,
+		})
+		return err
+	}
+	if o.pattern != "" {
+		if o.format != "" {
+			return fmt.Errorf("sync pattern and format are mutually exclusive")
+		}
+		s, err := versioned.NewRegexSyncer(o.pattern, o.template)
+		if err != nil {
+			return err
+		}
+		_, err = s.Sync(o.filePath, pkg, opts)
+		return err
+	}
+	_, err = versioned.SyncFile(o.filePath, pkg, o.format, opts)
+	return err
+}
+
+func printLdflags(o *ldflagsOptions) error {
+	result.Command = "ldflags"
+	vars, err := o.getVariables()
+	if err != nil {
+		return err
+	}
+	version, err := versioned.NewVersionFromFile(o.versionFile)
+	if err != nil {
+		return err
+	}
+	pkg := versioned.NewPackageManager("")
+	pkg.Version = version.String()
+	result.Version = pkg.Version
+
+	// The git metadata. Unlike the one in the Makefile, the commit has no
+	// "-dirty" suffix, because the dirty flag is set separately.
+	if pkg.Git.Branch, err = executeShell([]string{"git", "rev-parse", "--abbrev-ref", "HEAD", "--"}); err != nil {
+		return err
+	}
+	if pkg.Git.Commit, err = executeShell([]string{"git", "describe", "--always"}); err != nil {
+		return err
+	}
+	if pkg.Git.CommitDate, err = executeShell([]string{"git", "log", "-1", "--format=%cI"}); err != nil {
+		return err
+	}
+	// The tag is empty unless the commit is tagged.
+	pkg.Git.Tag, _ = executeShell([]string{"git", "describe", "--tags", "--exact-match"})
+	status, err := executeShell([]string{"git", "status", "--porcelain"})
+	if err != nil {
+		return err
+	}
+	pkg.Git.Dirty = status != ""
+
+	// The build metadata.
+	if u, err := user.Current(); err == nil {
+		pkg.Build.User = u.Username
+	}
+	if pkg.Build.Host, err = os.Hostname(); err != nil {
+		return err
+	}
+	if pkg.Build.Date, err = getBuildDate(o.buildDate, "."); err != nil {
+		return err
+	}
+	if pkg.Build.GoVersion, err = executeShell([]string{"go", "env", "GOVERSION"}); err != nil {
+		return err
+	}
+	cgo, err := executeShell([]string{"go", "env", "CGO_ENABLED"})
+	if err != nil {
+		return err
+	}
+	pkg.Build.CGO = cgo == "1"
+
+	ldflags := pkg.Ldflags(o.pkgPath, vars)
+	if o.extra != "" {
+		ldflags = o.extra + " " + ldflags
+	}
+	result.Ldflags = ldflags
+	if !o.build {
+		if !isStructuredOutput() {
+			fmt.Fprintln(os.Stdout, ldflags)
+		}
+		return nil
+	}
+	cmd := exec.Command("go", append([]string{"build", "-ldflags", ldflags}, o.buildArgs...)...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go build failed: %s", err)
+	}
+	return nil
+}
+
+func updateToc(o *tocOptions, fp string) error {
+	result.Command = "toc"
+	toc, err := o.newTableOfContents()
+	if err != nil {
+		return err
+	}
+	snapshot.add(fp)
+	toc.AddFilePath(fp)
+	if o.format != "" {
+		if err := toc.AddFormat(o.format); err != nil {
+			return err
+		}
+	}
+	err = versioned.UpdateToc(toc)
+	result.Warnings = toc.Warnings
+	printWarnings(toc.Warnings)
+	return err
+}
+
+func updateDocs(o *tocOptions, d *docsOptions) error {
+	result.Command = "docs"
+	toc, err := o.newTableOfContents()
+	if err != nil {
+		return err
+	}
+	docs := versioned.NewDocumentationSet()
+	docs.Toc = toc
+	if err := docs.AddDir(d.dir); err != nil {
+		return err
+	}
+	if err := docs.AddIndexFile(d.indexFile); err != nil {
+		return err
+	}
+	docs.AddTitle(d.title)
+	docs.AddOrder(strings.Split(d.order, ",")...)
+	snapshot.add(d.dir, filepath.Join(d.dir, d.indexFile))
+	err = versioned.UpdateDocs(docs)
+	result.Documents = docs.Documents
+	result.Warnings = docs.Warnings
+	result.BrokenLinks = docs.BrokenLinks
+	printWarnings(docs.Warnings)
+	if err != nil {
+		printBrokenLinks(docs.BrokenLinks)
+	}
+	return err
+}
+
+func checkLinks(slugStyle string, filePaths []string) error {
+	result.Command = "links"
+	c := versioned.NewLinkCheck()
+	if err := c.AddSlugStyle(slugStyle); err != nil {
+		return err
+	}
+	for _, fp := range filePaths {
+		if err := c.AddFilePath(fp); err != nil {
+			return err
+		}
+	}
+	if err := versioned.CheckLinks(c); err != nil {
+		result.BrokenLinks = c.BrokenLinks
+		printBrokenLinks(c.BrokenLinks)
+		return err
+	}
+	return nil
+}
+
+// newLicenseHeader returns the license header configured with the flags.
+func (o *licenseOptions) newLicenseHeader() (*versioned.LicenseHeader, error) {
+	lic := versioned.NewLicenseHeader()
+	if err := lic.AddFilePath(o.filePath); err != nil {
+		return nil, err
+	}
+	if o.authors != "" {
+		if err := lic.AddAuthors(o.authors); err != nil {
+			return nil, err
+		}
+	}
+	if len(o.copyrightHolders) == 0 && o.authors == "" {
+		return nil, fmt.Errorf("copyright holder is empty")
+	}
+	for _, holder := range o.copyrightHolders {
+		if err := lic.AddCopyrightHolder(holder); err != nil {
+			return nil, err
+		}
+	}
+	if err := lic.AddYear(o.year); err != nil {
+		return nil, err
+	}
+	if err := lic.AddLicenseType(o.licenseType); err != nil {
+		return nil, err
+	}
+	result.License = &licenseResult{
+		FilePath:         o.filePath,
+		Type:             lic.LicenseType,
+		CopyrightHolders: lic.CopyrightHolders,
+		Year:             lic.Year,
+	}
+	return lic, nil
+}
+
+// addLicense adds the license header to a file, or writes the license or
+// notice file, when the action is "file" or "notice".
+func addLicense(action string, o *licenseOptions) error {
+	result.Command = "license " + action
+	switch {
+	case action == "file" && o.filePath == "":
+		o.filePath = "LICENSE"
+	case action == "notice" && o.filePath == "":
+		o.filePath = "NOTICE"
+	}
+	snapshot.add(o.filePath)
+	lic, err := o.newLicenseHeader()
+	if err != nil {
+		return err
+	}
+	switch action {
+	case "file":
+		return versioned.WriteLicenseFile(lic, o.filePath)
+	case "notice":
+		return versioned.WriteNoticeFile(lic, o.filePath)
+	}
+	return versioned.AddLicense(lic)
+}
+
+func stripLicense(o *licenseOptions) error {
+	result.Command = "license strip"
+	snapshot.add(o.filePath)
+	lic := versioned.NewLicenseHeader()
+	if err := lic.AddFilePath(o.filePath); err != nil {
+		return err
+	}
+	result.License = &licenseResult{FilePath: o.filePath, Type: lic.LicenseType}
+	return versioned.StripLicense(lic)
+}
+
+func checkLicenseFile(o *licenseOptions) error {
+	result.Command = "license check"
+	if o.filePath == "" {
+		o.filePath = "LICENSE"
+	}
+	if o.licenseType == "" {
+		if len(o.sourcePaths) == 0 {
+			o.sourcePaths = stringList{"."}
+		}
+		licenseType, err := versioned.CheckLicenseFileHeaders(o.filePath, o.sourcePaths...)
+		if licenseType != "" {
+			result.License = &licenseResult{FilePath: o.filePath, Type: licenseType}
+		}
+		return err
+	}
+	lic := versioned.NewLicenseHeader()
+	if err := lic.AddLicenseType(o.licenseType); err != nil {
+		return err
+	}
+	result.License = &licenseResult{FilePath: o.filePath, Type: lic.LicenseType}
+	return versioned.CheckLicenseFile(lic, o.filePath)
+}
+
+func reportDependencies(o *depsOptions) error {
+	result.Command = "license deps"
+	if o.filePath == "" {
+		o.filePath = "go.mod"
+	}
+	report := versioned.NewDependencyReport()
+	if err := report.AddFilePath(o.filePath); err != nil {
+		return err
+	}
+	for _, s := range strings.Split(o.deniedLicenses, ",") {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		if err := report.AddDeniedLicense(s); err != nil {
+			return err
+		}
+	}
+	if err := versioned.ScanDependencies(report); err != nil {
+		return err
+	}
+	result.Dependencies = report.Dependencies
+	if !isStructuredOutput() {
+		b, err := report.Render(o.reportFormat)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "%s", b)
+	}
+	if denied := report.Denied(); len(denied) > 0 {
+		if !isStructuredOutput() {
+			for _, dep := range denied {
+				fmt.Fprintf(os.Stderr, "dependency %s %s has denied license %s\n", dep.Path, dep.Version, dep.License)
+			}
+		}
+		return fmt.Errorf("found %d dependencies with denied licenses", len(denied))
+	}
+	return nil
+}
@@ -0,0 +1,436 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// command is a subcommand of the CLI, e.g. "versioned bump".
+type command struct {
+	name        string
+	args        string
+	description string
+	flags       *flag.FlagSet
+	// run executes the command with the arguments following the flags.
+	run func(args []string) error
+	// commands are the actions of the command, e.g. "versioned license add".
+	commands []*command
+	// noResult is true when the output of the command is not followed by
+	// the command result, e.g. the version information.
+	noResult bool
+}
+
+// newCommand returns an instance of command with the flag set including
+// the output format flag.
+func newCommand(name, args, description string) *command {
+	c := &command{
+		name:        name,
+		args:        args,
+		description: description,
+		flags:       flag.NewFlagSet(app.Name+" "+name, flag.ExitOnError),
+	}
+	c.flags.Func("output", "output format, i.e. text, json, or yaml (default \"text\")", setOutputFormat)
+	c.flags.Usage = c.usage
+	return c
+}
+
+func (c *command) usage() {
+	fmt.Fprintf(os.Stderr, "\nUsage: %s %s %s\n\n", app.Name, c.name, c.args)
+	fmt.Fprintf(os.Stderr, "%s\n", c.description)
+	if len(c.commands) > 0 {
+		fmt.Fprintf(os.Stderr, "\nCommands:\n")
+		for _, cmd := range c.commands {
+			fmt.Fprintf(os.Stderr, "  %-9s %s\n", strings.TrimPrefix(cmd.name, c.name+" "), cmd.description)
+		}
+		fmt.Fprintf(os.Stderr, "\nRun \"%s help %s <command>\" for the flags of a command.\n\n", app.Name, c.name)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "\nFlags:\n")
+	c.flags.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\n")
+}
+
+// execute parses the flags and runs the command, or the action named by
+// the first argument.
+func (c *command) execute(args []string) error {
+	if len(c.commands) > 0 {
+		if len(args) == 0 {
+			c.usage()
+			return fmt.Errorf("%s command is required", c.name)
+		}
+		cmd := getCommand(c.commands, args[0])
+		if cmd == nil {
+			return fmt.Errorf("unknown %s command %q", c.name, args[0])
+		}
+		return cmd.execute(args[1:])
+	}
+	// The arguments after "--" are not parsed, e.g. the ones of go build in
+	// "versioned ldflags -build -- -o bin/app ./cmd/app".
+	var rest []string
+	for i, arg := range args {
+		if arg == "--" {
+			args, rest = args[:i], args[i+1:]
+			break
+		}
+	}
+	// The flags may follow the arguments, e.g. "versioned bump minor -silent".
+	var positional []string
+	for {
+		c.flags.Parse(args)
+		args = c.flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	return c.run(append(positional, rest...))
+}
+
+// getCommand returns the command with the provided name, or the last word
+// of the name.
+func getCommand(commands []*command, name string) *command {
+	for _, c := range commands {
+		if c.name == name || strings.HasSuffix(c.name, " "+name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// getCommands returns the subcommands of the CLI.
+func getCommands() []*command {
+	return []*command{
+		newInitCommand(),
+		newShowCommand(),
+		newBumpCommand(),
+		newSyncCommand(),
+		newLdflagsCommand(),
+		newTocCommand(),
+		newDocsCommand(),
+		newLinksCommand(),
+		newLicenseCommand(),
+		newVersionCommand(),
+	}
+}
+
+// usage prints the subcommands of the CLI, followed by the deprecated
+// flags.
+func usage() {
+	fmt.Fprintf(os.Stderr, "\n%s - %s\n\n", app.Name, app.Description)
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags] [arguments]\n\n", app.Name)
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	for _, c := range getCommands() {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", c.name, c.description)
+	}
+	fmt.Fprintf(os.Stderr, "  %-9s %s\n", "help", "show the flags of a command")
+	fmt.Fprintf(os.Stderr, "\nRun \"%s help <command>\" for the flags of a command.\n", app.Name)
+	fmt.Fprintf(os.Stderr, "\nFlags (deprecated, except -version):\n")
+	fs := flag.NewFlagSet(app.Name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	addLegacyFlags(fs)
+	fs.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\nDocumentation: %s\n\n", app.Documentation)
+}
+
+// help prints the usage of the command named by the arguments.
+func help(args []string) error {
+	commands := getCommands()
+	if len(args) == 0 {
+		usage()
+		return nil
+	}
+	cmd := getCommand(commands, args[0])
+	for _, s := range args[1:] {
+		if cmd == nil {
+			break
+		}
+		cmd = getCommand(cmd.commands, s)
+	}
+	if cmd == nil {
+		return fmt.Errorf("unknown command %q", strings.Join(args, " "))
+	}
+	cmd.usage()
+	return nil
+}
+
+// checkArgs returns an error when there are more arguments than expected.
+func checkArgs(c *command, args []string, max int) error {
+	if len(args) > max {
+		return fmt.Errorf("%s command has unexpected arguments: %s", c.name, strings.Join(args[max:], " "))
+	}
+	return nil
+}
+
+func newInitCommand() *command {
+	c := newCommand("init", "[flags]", "initialize a new version file")
+	var versionFile string
+	c.flags.StringVar(&versionFile, "source", "VERSION", "The \"source of truth\" file with version info")
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 0); err != nil {
+			return err
+		}
+		return initVersion(versionFile)
+	}
+	return c
+}
+
+func newShowCommand() *command {
+	c := newCommand("show", "[flags]", "print the current version")
+	var versionFile string
+	c.flags.StringVar(&versionFile, "source", "VERSION", "The \"source of truth\" file with version info")
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 0); err != nil {
+			return err
+		}
+		return showVersion(versionFile)
+	}
+	return c
+}
+
+func newBumpCommand() *command {
+	c := newCommand("bump", "[flags] [major|minor|patch]", "increment major, minor, or patch version, default: patch")
+	o := &bumpOptions{}
+	c.flags.StringVar(&o.versionFile, "source", "VERSION", "The \"source of truth\" file with version info")
+	c.flags.Uint64Var(&o.factor, "factor", 1, "increase factor")
+	c.flags.BoolVar(&o.silent, "silent", false, "silent execution")
+	c.flags.Var(&o.generateGo, "generate-go", "regenerate Go `FILE` created with \"sync -generate go\", repeatable")
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 1); err != nil {
+			return err
+		}
+		part := "patch"
+		if len(args) > 0 {
+			part = args[0]
+		}
+		switch part {
+		case "major":
+			o.major = true
+		case "minor":
+			o.minor = true
+		case "patch":
+			o.patch = true
+		default:
+			return fmt.Errorf("version part %q is unsupported, i.e. major, minor, or patch", part)
+		}
+		return bumpVersion(o)
+	}
+	return c
+}
+
+func newSyncCommand() *command {
+	c := newCommand("sync", "[flags] FILE", "synchronize info from version file to a file")
+	o := &syncOptions{}
+	c.flags.StringVar(&o.versionFile, "source", "VERSION", "The \"source of truth\" file with version info")
+	c.flags.StringVar(&o.format, "format", "", "synchronize according to specific language, i.e. py, js, go, ts, etc.")
+	c.flags.StringVar(&o.pattern, "pattern", "", "synchronize the text captured by `REGEX`, e.g. 'LABEL version=\"(.*)\"'")
+	c.flags.StringVar(&o.template, "template", "{{.Version}}", "text/template of the text captured by -pattern, e.g. {{.Major}}.{{.Minor}}")
+	c.flags.StringVar(&o.goVar, "go-var", "", "synchronize Go constant or variable `NAME`, e.g. Version, instead of versioned package calls")
+	c.flags.BoolVar(&o.preRelease, "prerelease", false, "mark as pre-release for sync purposes")
+	c.flags.BoolVar(&o.release, "release", false, "omits commit version when syncing")
+	c.flags.StringVar(&o.buildUser, "build-user", "", "synchronize build user to Go files")
+	c.flags.StringVar(&o.buildDate, "build-date", "", "synchronize build `DATE` to Go files, \"now\", or \"source\" for SOURCE_DATE_EPOCH or commit date")
+	c.flags.StringVar(&o.description, "description", "", "synchronize package description to Go files")
+	c.flags.StringVar(&o.documentation, "documentation", "", "synchronize package documentation `URL` to Go files")
+	c.flags.StringVar(&o.generate, "generate", "", "generate complete file of `FORMAT`, i.e. go, instead of synchronizing existing one")
+	c.flags.StringVar(&o.goPackage, "go-package", "", "the package `NAME` of the generated Go file, default: the one of the directory or main")
+	c.run = func(args []string) error {
+		if len(args) == 0 {
+			return fmt.Errorf("sync command requires file path")
+		}
+		if err := checkArgs(c, args, 1); err != nil {
+			return err
+		}
+		o.filePath = args[0]
+		return syncVersion(o)
+	}
+	return c
+}
+
+func newLdflagsCommand() *command {
+	c := newCommand("ldflags", "[flags] [-build [--] [GO BUILD ARGS...]]", "print -X linker flags for version info, or go build with them")
+	o := &ldflagsOptions{}
+	c.flags.StringVar(&o.versionFile, "source", "VERSION", "The \"source of truth\" file with version info")
+	c.flags.StringVar(&o.pkgPath, "package", "main", "the `PATH` of the package with the variables")
+	c.flags.Var(&o.vars, "var", "the `KEY=NAME` of the variable, e.g. version=Version, git_commit=, keys: "+strings.Join(ldflagsKeys, ", "))
+	c.flags.StringVar(&o.buildDate, "build-date", "now", "build `DATE`, \"now\", or \"source\" for SOURCE_DATE_EPOCH or commit date")
+	c.flags.StringVar(&o.extra, "extra", "", "additional linker `FLAGS`, e.g. \"-w -s\"")
+	c.flags.BoolVar(&o.build, "build", false, "run go build with the linker flags and the arguments")
+	c.run = func(args []string) error {
+		if !o.build {
+			if err := checkArgs(c, args, 0); err != nil {
+				return err
+			}
+		}
+		o.buildArgs = args
+		return printLdflags(o)
+	}
+	return c
+}
+
+func newVersionCommand() *command {
+	c := newCommand("version", "[flags]", "print version information of "+app.Name)
+	c.noResult = true
+	var short bool
+	c.flags.BoolVar(&short, "short", false, "print name and version only")
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 0); err != nil {
+			return err
+		}
+		format := outputFormat
+		if short {
+			if isStructuredOutput() {
+				return fmt.Errorf("version -short and -output %s are mutually exclusive", outputFormat)
+			}
+			format = "short"
+		}
+		return app.WriteVersion(os.Stdout, format)
+	}
+	return c
+}
+
+func newTocCommand() *command {
+	c := newCommand("toc", "[flags] [FILE]", "update table of contents of a document, default: README.md")
+	o := &tocOptions{}
+	o.addFlags(c.flags, "")
+	c.flags.StringVar(&o.format, "format", "", "document format, i.e. markdown, rst, or asciidoc, default: by file extension")
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 1); err != nil {
+			return err
+		}
+		fp := "README.md"
+		if len(args) > 0 {
+			fp = args[0]
+		}
+		return updateToc(o, fp)
+	}
+	return c
+}
+
+func newDocsCommand() *command {
+	c := newCommand("docs", "[flags] [DIR]", "update tables of contents and index of Markdown documents in a directory, default: docs")
+	o := &tocOptions{}
+	o.addFlags(c.flags, "toc-")
+	d := &docsOptions{}
+	c.flags.StringVar(&d.indexFile, "index", "index.md", "documentation index file, relative to the directory")
+	c.flags.StringVar(&d.title, "title", "Documentation", "documentation index title")
+	c.flags.StringVar(&d.order, "order", "", "comma-separated list of documents listed first in the index")
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 1); err != nil {
+			return err
+		}
+		d.dir = "docs"
+		if len(args) > 0 {
+			d.dir = args[0]
+		}
+		return updateDocs(o, d)
+	}
+	return c
+}
+
+func newLinksCommand() *command {
+	c := newCommand("links", "[flags] [FILE...]", "check anchor and relative links of Markdown files, default: README.md")
+	var slugStyle string
+	c.flags.StringVar(&slugStyle, "slug", "github", "heading anchor style, i.e. github, gitlab, bitbucket, or hugo")
+	c.run = func(args []string) error {
+		if len(args) == 0 {
+			args = []string{"README.md"}
+		}
+		return checkLinks(slugStyle, args)
+	}
+	return c
+}
+
+func newLicenseCommand() *command {
+	c := newCommand("license", "<command> [flags] [FILE]", "add, strip, or check license headers and files")
+	c.commands = []*command{
+		newAddLicenseCommand("add", "[flags] FILE", "add license header to a file"),
+		newStripLicenseCommand(),
+		newAddLicenseCommand("file", "[flags] [FILE]", "write full license text to a file, default: LICENSE"),
+		newAddLicenseCommand("notice", "[flags] [FILE]", "write copyright holders to a file, default: NOTICE"),
+		newCheckLicenseCommand(),
+		newDepsCommand(),
+	}
+	return c
+}
+
+func newAddLicenseCommand(action, args, description string) *command {
+	c := newCommand("license "+action, args, description)
+	o := &licenseOptions{}
+	o.addFlags(c.flags, "type")
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 1); err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			o.filePath = args[0]
+		}
+		return addLicense(action, o)
+	}
+	return c
+}
+
+func newStripLicenseCommand() *command {
+	c := newCommand("license strip", "[flags] FILE", "strip license header from a file")
+	o := &licenseOptions{}
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 1); err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			o.filePath = args[0]
+		}
+		return stripLicense(o)
+	}
+	return c
+}
+
+func newCheckLicenseCommand() *command {
+	c := newCommand("license check", "[flags] [FILE]", "check the license type of a file matches source headers, default: LICENSE")
+	o := &licenseOptions{}
+	c.flags.StringVar(&o.licenseType, "type", "", "expected license type, default: the one of the license headers of source files")
+	c.flags.Var(&o.sourcePaths, "dir", "source `PATH` with license headers, repeatable, default: current directory")
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 1); err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			o.filePath = args[0]
+		}
+		return checkLicenseFile(o)
+	}
+	return c
+}
+
+func newDepsCommand() *command {
+	c := newCommand("license deps", "[flags] [FILE]", "report licenses of Go module dependencies, default: go.mod")
+	o := &depsOptions{}
+	c.flags.StringVar(&o.reportFormat, "format", "markdown", "dependency license report format, i.e. markdown, csv, json")
+	c.flags.StringVar(&o.deniedLicenses, "deny", "agpl3", "comma-separated list of denied dependency licenses")
+	c.run = func(args []string) error {
+		if err := checkArgs(c, args, 1); err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			o.filePath = args[0]
+		}
+		return reportDependencies(o)
+	}
+	return c
+}
@@ -15,27 +15,29 @@
 package main
 
 import (
-	"bufio"
 	"bytes"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
-	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/greenpau/versioned"
 )
 
 var (
-	app        *versioned.PackageManager
-	appVersion string
-	gitBranch  string
-	gitCommit  string
-	buildUser  string
-	buildDate  string
+	app            *versioned.PackageManager
+	appVersion     string
+	gitBranch      string
+	gitCommit      string
+	gitTag         string
+	gitCommitDate  string
+	gitDirty       string
+	buildUser      string
+	buildDate      string
+	buildHost      string
+	buildGoVersion string
+	buildCgo       string
 )
 
 func init() {
@@ -47,551 +49,170 @@ func init() {
 	app.SetGitCommit(gitCommit, "1.0.36")
 	app.SetBuildUser(buildUser, "")
 	app.SetBuildDate(buildDate, "")
+	app.SetGitTag(gitTag, "")
+	app.SetGitCommitDate(gitCommitDate, "")
+	app.SetGitDirty(gitDirty, "")
+	app.SetBuildHost(buildHost, "")
+	app.SetBuildGoVersion(buildGoVersion, "")
+	app.SetBuildCGO(buildCgo, "")
+	app.FromBuildInfo()
 }
 
 func main() {
-	var versionedDir string
-	var versionFile string
-	var isShowVersion bool
-	var isIncrementMajor bool
-	var isIncrementMinor bool
-	var isIncrementPatch bool
-	var isInitialize bool
-	var isSilent bool
-	var isRelease bool
-	var factor uint64
-	var syncFilePath string
-	var syncFileFormat string
-	var isPreRelease bool
-	var isTocUpdate, isAddLicense, isStripLicense bool
-	var targetFilePath string
-	var licenseCopyrightHolder, licenseType string
-	var licenseCopyrightYear uint64
-
-	flag.StringVar(&versionedDir, "path", "./", "The path to data repository")
-	flag.StringVar(&versionFile, "source", "VERSION", "The \"source of truth\" file with version info")
-	flag.BoolVar(&isInitialize, "init", false, "initialize a new version file")
-	flag.StringVar(&syncFilePath, "sync", "", "synchronize info from version file to `FILE`")
-	flag.BoolVar(&isPreRelease, "prerelease", false, "mark as pre-release for sync purposes")
-
-	flag.StringVar(&syncFileFormat, "format", "", "synchronize according to specific language, i.e. py, js, go, ts, etc.")
-	flag.BoolVar(&isIncrementMajor, "major", false, "increment major version")
-	flag.BoolVar(&isIncrementMinor, "minor", false, "increment minor version")
-	flag.BoolVar(&isIncrementPatch, "patch", false, "increment patch version")
-
-	flag.StringVar(&targetFilePath, "filepath", "", "target file path")
-
-	// Markdown Table of Contents flags.
-	flag.BoolVar(&isTocUpdate, "toc", false, "update table of contents")
-
-	// License flags.
-	flag.BoolVar(&isAddLicense, "addlicense", false, "add license header a file")
-	flag.BoolVar(&isStripLicense, "striplicense", false, "strip license header from a file")
-	flag.StringVar(&licenseType, "license", "apache", "license type")
-	flag.StringVar(&licenseCopyrightHolder, "copyright", "", "license copyright holder")
-	flag.Uint64Var(&licenseCopyrightYear, "year", 0, "copyright year")
-
-	flag.BoolVar(&isRelease, "release", false, "omits commit version when syncing")
-	flag.Uint64Var(&factor, "factor", 1, "increase factor")
-	flag.BoolVar(&isSilent, "silent", false, "silent execution")
-	flag.BoolVar(&isShowVersion, "version", false, "version information")
-	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "\n%s - %s\n\n", app.Name, app.Description)
-		fmt.Fprintf(os.Stderr, "Usage: %s [arguments]\n\n", app.Name)
-		flag.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nDocumentation: %s\n\n", app.Documentation)
-	}
-	flag.Parse()
-	if isShowVersion {
-		fmt.Fprintf(os.Stdout, "%s\n", app.Banner())
-		os.Exit(0)
-	}
-
-	if isInitialize {
-		if version, err := versioned.NewVersionFromFile(versionFile); err == nil {
-			fmt.Fprintf(os.Stderr, "version file already exists, version: %s\n", version)
-			os.Exit(0)
-		}
-		version, _ := versioned.NewVersion("1.0.0")
-		if err := version.SetFile(versionFile); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to initialize version file: %s\n", err)
-			os.Exit(1)
-		}
-		if err := version.UpdateFile(); err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to initialize new version file: %s\n", err)
-			os.Exit(1)
-		}
-		os.Exit(0)
-	}
-
-	switch {
-	case isTocUpdate:
-		if targetFilePath == "" {
-			targetFilePath = "README.md"
-		}
-		toc := versioned.NewTableOfContents()
-		toc.AddFilePath(targetFilePath)
-		if err := versioned.UpdateToc(toc); err != nil {
-			exitWithError(err)
-		}
-		os.Exit(0)
-	case isAddLicense:
-		lic := versioned.NewLicenseHeader()
-		if err := lic.AddFilePath(targetFilePath); err != nil {
-			exitWithError(err)
-		}
-		if err := lic.AddCopyrightHolder(licenseCopyrightHolder); err != nil {
-			exitWithError(err)
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		var err error
+		cmd := getCommand(getCommands(), args[0])
+		switch {
+		case args[0] == "help":
+			err = help(args[1:])
+		case cmd != nil:
+			err = cmd.execute(args[1:])
+		default:
+			err = fmt.Errorf("unknown command %q, run \"%s help\" for the list of commands", args[0], app.Name)
 		}
-		if err := lic.AddYear(licenseCopyrightYear); err != nil {
-			exitWithError(err)
-		}
-		if err := lic.AddLicenseType(licenseType); err != nil {
-			exitWithError(err)
-		}
-		if err := versioned.AddLicense(lic); err != nil {
-			exitWithError(err)
-		}
-		os.Exit(0)
-	case isStripLicense:
-		lic := versioned.NewLicenseHeader()
-		if err := lic.AddFilePath(targetFilePath); err != nil {
-			exitWithError(err)
-		}
-		if err := versioned.StripLicense(lic); err != nil {
+		if err != nil {
 			exitWithError(err)
 		}
-		os.Exit(0)
-	}
-
-	version, err := versioned.NewVersionFromFile(versionFile)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "%s\n", err)
-		os.Exit(1)
-	}
-
-	oldVersion := *version
-
-	if !isIncrementMajor && !isIncrementMinor && !isIncrementPatch && syncFilePath == "" && !isTocUpdate {
-		fmt.Fprintf(os.Stdout, "%s\n", version)
-		os.Exit(0)
-	}
-
-	if isIncrementMajor {
-		version.IncrementMajor(factor)
-		if !isSilent {
-			fmt.Fprintf(os.Stderr, "increased major version by %d, current version: %s\n",
-				factor, version,
-			)
-		}
-	}
-
-	if isIncrementMinor {
-		version.IncrementMinor(factor)
-		if !isSilent {
-			fmt.Fprintf(os.Stderr, "increased minor version by %d, current version: %s\n",
-				factor, version,
-			)
+		if cmd != nil && cmd.noResult {
+			os.Exit(0)
 		}
+		exitWithResult()
 	}
-
-	if isIncrementPatch {
-		version.IncrementPatch(factor)
-		if !isSilent {
-			fmt.Fprintf(os.Stderr, "increased patch version by %d, current version: %s\n",
-				factor, version,
-			)
-		}
+	if err := runLegacy(args); err != nil {
+		exitWithError(err)
 	}
+	exitWithResult()
+}
 
-	if isIncrementMajor || isIncrementMinor || isIncrementPatch {
-		if err := version.UpdateFile(); err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-			os.Exit(1)
-		}
+// legacyOptions are the flags preceding the subcommands.
+type legacyOptions struct {
+	versionedDir   string
+	isShowVersion  bool
+	isInitialize   bool
+	syncFilePath   string
+	isTocUpdate    bool
+	isAddLicense   bool
+	isStripLicense bool
+	targetFilePath string
+	output         string
+	bump           *bumpOptions
+	sync           *syncOptions
+	toc            *tocOptions
+	lic            *licenseOptions
+}
 
-		if !isSilent {
-			fmt.Fprintf(os.Stderr, "updated version: %s, previous version: %s\n",
-				version, &oldVersion,
-			)
-		}
+// addLegacyFlags adds the flags preceding the subcommands to the flag set.
+// The flags of the features added with the subcommands are not included.
+func addLegacyFlags(fs *flag.FlagSet) *legacyOptions {
+	o := &legacyOptions{
+		bump: &bumpOptions{},
+		sync: &syncOptions{},
+		toc:  &tocOptions{},
+		lic:  &licenseOptions{},
 	}
+	fs.StringVar(&o.versionedDir, "path", "./", "The path to data repository")
+	fs.StringVar(&o.bump.versionFile, "source", "VERSION", "The \"source of truth\" file with version info")
+	fs.BoolVar(&o.isInitialize, "init", false, "initialize a new version file")
+	fs.StringVar(&o.syncFilePath, "sync", "", "synchronize info from version file to `FILE`")
+	fs.BoolVar(&o.sync.preRelease, "prerelease", false, "mark as pre-release for sync purposes")
 
-	if syncFilePath != "" {
-		fi, err := os.Stat(syncFilePath)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-			os.Exit(1)
-		}
-		if !fi.Mode().IsRegular() {
-			fmt.Fprintf(os.Stderr, "path %s is not a file\n", syncFilePath)
-			os.Exit(1)
-		}
+	fs.StringVar(&o.sync.format, "format", "", "synchronize according to specific language, i.e. py, js, go, ts, etc.")
+	fs.BoolVar(&o.bump.major, "major", false, "increment major version")
+	fs.BoolVar(&o.bump.minor, "minor", false, "increment minor version")
+	fs.BoolVar(&o.bump.patch, "patch", false, "increment patch version")
 
-		commit, err := executeShell([]string{"git", "describe", "--always"})
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-			os.Exit(1)
-		}
-		branch, err := executeShell([]string{"git", "rev-parse", "--abbrev-ref", "HEAD", "--"})
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "%s\n", err)
-			os.Exit(1)
-		}
+	fs.StringVar(&o.targetFilePath, "filepath", "", "target file path")
 
-		if isRelease {
-			branch = ""
-			commit = ""
-		}
-
-		pkg := versioned.NewPackageManager("")
-		pkg.Version = version.String()
-		pkg.Git.Branch = branch
-		pkg.Git.Commit = commit
-
-		ext := filepath.Ext(syncFilePath)
-		fileDir, fileName := filepath.Split(syncFilePath)
-		if syncFileFormat == "blender" {
-			if err := syncBlenderFile(pkg, syncFilePath, fi); err != nil {
-				fmt.Fprintf(os.Stderr, "%s\n", err)
-				os.Exit(1)
-			}
-			os.Exit(0)
-		}
-		if strings.HasSuffix(syncFilePath, "package.json") {
-			if err := syncPackageJSON(pkg, syncFilePath, fi); err != nil {
-				fmt.Fprintf(os.Stderr, "%s\n", err)
-				os.Exit(1)
-			}
-			os.Exit(0)
-		}
-		if ext == ".py" || syncFileFormat == "py" || syncFileFormat == "python" {
-			if err := syncPythonFile(pkg, syncFilePath, fi); err != nil {
-				fmt.Fprintf(os.Stderr, "%s\n", err)
-				os.Exit(1)
-			}
-			os.Exit(0)
-		}
-		if ext == ".go" {
-			if err := syncGolangFile(pkg, isPreRelease, syncFilePath, fi); err != nil {
-				fmt.Fprintf(os.Stderr, "%s\n", err)
-				os.Exit(1)
-			}
-			os.Exit(0)
-		}
-		if ext == ".ts" || ext == ".js" {
-			if err := syncJavascriptFile(pkg, syncFilePath, fi); err != nil {
-				fmt.Fprintf(os.Stderr, "%s\n", err)
-				os.Exit(1)
-			}
-			os.Exit(0)
-		}
-
-		fmt.Fprintf(os.Stderr, "file %s in %s directory has unsupported file extension %s\n", fileName, fileDir, ext)
-		os.Exit(1)
-	}
+	// Markdown Table of Contents flags.
+	fs.BoolVar(&o.isTocUpdate, "toc", false, "update table of contents")
+	// The table of contents has the defaults of the toc command.
+	o.toc.addFlags(flag.NewFlagSet("toc", flag.ContinueOnError), "")
 
-	os.Exit(0)
+	// License flags.
+	fs.BoolVar(&o.isAddLicense, "addlicense", false, "add license header a file")
+	fs.BoolVar(&o.isStripLicense, "striplicense", false, "strip license header from a file")
+	fs.StringVar(&o.lic.licenseType, "license", "apache", "license type")
+	fs.Var(&o.lic.copyrightHolders, "copyright", "license copyright holder, repeat for multiple holders")
+	fs.Uint64Var(&o.lic.year, "year", 0, "copyright year")
+
+	fs.BoolVar(&o.sync.release, "release", false, "omits commit version when syncing")
+	fs.Uint64Var(&o.bump.factor, "factor", 1, "increase factor")
+	fs.BoolVar(&o.bump.silent, "silent", false, "silent execution")
+	fs.BoolVar(&o.isShowVersion, "version", false, "version information")
+	fs.StringVar(&o.output, "output", "text", "output format, i.e. text, json, or yaml")
+	return o
 }
 
-func syncJavascriptFile(pkg *versioned.PackageManager, fp string, fi os.FileInfo) error {
-	var buffer bytes.Buffer
-	fh, err := os.Open(fp)
-	if err != nil {
+// runLegacy runs the command selected with the flags preceding the
+// subcommands, e.g. "versioned -patch". The flags, except -version, are
+// deprecated.
+func runLegacy(args []string) error {
+	o := addLegacyFlags(flag.CommandLine)
+	flag.Usage = usage
+	flag.CommandLine.Parse(args)
+	if err := setOutputFormat(o.output); err != nil {
 		return err
 	}
-	defer fh.Close()
-
-	isVersionFound := false
-	fileVersion := ""
 
-	scanner := bufio.NewScanner(fh)
-	for scanner.Scan() {
-		line := scanner.Text()
-		if strings.Contains(line, "Version: ") {
-			isVersionFound = true
-			v := strings.SplitN(line, ":", 2)[1]
-			v = strings.TrimSpace(v)
-			v = strings.Replace(v, ",", "", -1)
-			v = strings.Replace(v, "'", "", -1)
-			v = strings.Replace(v, "\"", "", -1)
-			v = strings.TrimSpace(v)
-			fileVersion = v
-			if fileVersion != pkg.Version {
-				buffer.WriteString(strings.ReplaceAll(line, fileVersion, pkg.Version) + "\n")
-			} else {
-				buffer.WriteString(line + "\n")
-			}
-			continue
+	if o.isShowVersion {
+		if err := app.WriteVersion(os.Stdout, outputFormat); err != nil {
+			return err
 		}
-		buffer.WriteString(line + "\n")
-	}
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-	fh.Close()
-	ref := "Please see https://github.com/greenpau/versioned#nodejs-javascript-typescript"
-	if !isVersionFound {
-		return fmt.Errorf("version not found. %s", ref)
-	}
-	if pkg.Version != fileVersion {
-		mode := fi.Mode()
-		return ioutil.WriteFile(fp, buffer.Bytes(), mode.Perm())
+		os.Exit(0)
 	}
-	return nil
-}
 
-// syncPythonFile inspects a Python file for __version__ module level
-// dunder (see PEP 8) and, if necessary, updates the version to
-// match the one found in VERSION file.
-func syncPythonFile(pkg *versioned.PackageManager, fp string, fi os.FileInfo) error {
-	var buffer bytes.Buffer
-	fh, err := os.Open(fp)
-	if err != nil {
-		return err
+	if o.isInitialize {
+		printDeprecation("init", "init")
+		return initVersion(o.bump.versionFile)
 	}
-	defer fh.Close()
 
-	isVersionDunderExist := false
-	fileVersion := ""
-	versionDunder := "__version__"
-
-	scanner := bufio.NewScanner(fh)
-	for scanner.Scan() {
-		line := scanner.Text()
-		line = line + "\n"
-		if strings.HasPrefix(line, versionDunder) {
-			isVersionDunderExist = true
-			v := strings.SplitN(line, "=", 2)[1]
-			v = strings.TrimSpace(v)
-			v = strings.Replace(v, "'", "", -1)
-			v = strings.Replace(v, "\"", "", -1)
-			fileVersion = v
-			if fileVersion != pkg.Version {
-				buffer.WriteString("__version__ = '" + pkg.Version + "'\n")
-			} else {
-				buffer.WriteString(line)
-			}
-			continue
+	o.lic.filePath = o.targetFilePath
+	switch {
+	case o.isTocUpdate:
+		printDeprecation("toc", "toc")
+		if o.targetFilePath == "" {
+			o.targetFilePath = "README.md"
 		}
-		buffer.WriteString(line)
-	}
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-	fh.Close()
-	ref := "Please see https://github.com/greenpau/versioned#package-metadata"
-	if !isVersionDunderExist {
-		return fmt.Errorf("%s module level dunder not found. %s", versionDunder, ref)
+		return updateToc(o.toc, o.targetFilePath)
+	case o.isAddLicense:
+		printDeprecation("addlicense", "license add")
+		return addLicense("add", o.lic)
+	case o.isStripLicense:
+		printDeprecation("striplicense", "license strip")
+		return stripLicense(o.lic)
 	}
-	if pkg.Version != fileVersion {
-		mode := fi.Mode()
-		return ioutil.WriteFile(fp, buffer.Bytes(), mode.Perm())
-	}
-	return nil
-}
 
-// syncBlenderFile inspects a Python file for bl_info["version"] and,
-// if necessary, updates it to match the version found in VERSION file.
-func syncBlenderFile(pkg *versioned.PackageManager, fp string, fi os.FileInfo) error {
-	var buffer bytes.Buffer
-
-	fh, err := os.Open(fp)
-	if err != nil {
-		return err
+	if !o.bump.major && !o.bump.minor && !o.bump.patch && o.syncFilePath == "" {
+		return showVersion(o.bump.versionFile)
 	}
-	defer fh.Close()
 
-	scanner := bufio.NewScanner(fh)
-
-	inBlInfo := false
-	versionFound := false
-	fileVersion := ""
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		trimmed := strings.TrimSpace(line)
-
-		// Detect start of bl_info dictionary
-		if strings.HasPrefix(trimmed, "bl_info") && strings.Contains(trimmed, "{") {
-			inBlInfo = true
+	if o.bump.major || o.bump.minor || o.bump.patch {
+		switch {
+		case o.bump.major:
+			printDeprecation("major", "bump major")
+		case o.bump.minor:
+			printDeprecation("minor", "bump minor")
+		default:
+			printDeprecation("patch", "bump patch")
 		}
-
-		if inBlInfo && strings.HasPrefix(trimmed, "\"version\"") ||
-			inBlInfo && strings.HasPrefix(trimmed, "'version'") {
-
-			versionFound = true
-
-			// Extract tuple portion: (1, 0, 0)
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) != 2 {
-				return fmt.Errorf("invalid bl_info version format")
-			}
-
-			raw := strings.TrimSpace(parts[1])
-			raw = strings.TrimSuffix(raw, ",")
-			raw = strings.TrimSpace(raw)
-
-			// Convert tuple "(1, 2, 3)" -> "1.2.3"
-			raw = strings.TrimPrefix(raw, "(")
-			raw = strings.TrimSuffix(raw, ")")
-			raw = strings.ReplaceAll(raw, " ", "")
-			fileVersion = strings.ReplaceAll(raw, ",", ".")
-
-			if fileVersion != pkg.Version {
-				// Convert pkg.Version "1.2.3" -> (1, 2, 3)
-				versionParts := strings.Split(pkg.Version, ".")
-				newTuple := "(" + strings.Join(versionParts, ", ") + ")"
-				buffer.WriteString(fmt.Sprintf(`    "version": %s,`+"\n", newTuple))
-			} else {
-				buffer.WriteString(line + "\n")
-			}
-
-			continue
+		if err := bumpVersion(o.bump); err != nil {
+			return err
 		}
-
-		// Detect end of bl_info dictionary
-		if inBlInfo && strings.Contains(trimmed, "}") {
-			inBlInfo = false
-		}
-
-		buffer.WriteString(line + "\n")
-	}
-
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
-	if !versionFound {
-		return fmt.Errorf("bl_info['version'] not found")
 	}
 
-	if fileVersion != pkg.Version {
-		mode := fi.Mode()
-		return ioutil.WriteFile(fp, buffer.Bytes(), mode.Perm())
+	if o.syncFilePath != "" {
+		printDeprecation("sync", "sync")
+		o.sync.versionFile = o.bump.versionFile
+		o.sync.filePath = o.syncFilePath
+		return syncVersion(o.sync)
 	}
-
 	return nil
 }
 
-func syncGolangFile(pkg *versioned.PackageManager, isPreRelease bool, fp string, _ os.FileInfo) error {
-	var buffer bytes.Buffer
-	fh, err := os.Open(fp)
-	if err != nil {
-		return err
-	}
-	defer fh.Close()
-
-	isPackageIncluded := false
-	isPackageInitialized := false
-	foundVersionMatch := false
-	rewrite := false
-	pkgName := "github.com/greenpau/versioned"
-	isInsideInit := 0
-
-	scanner := bufio.NewScanner(fh)
-	for scanner.Scan() {
-		line := scanner.Text()
-		line = line + "\n"
-		//fmt.Fprintf(os.Stderr, "%s", line)
-		if strings.Contains(line, pkgName) {
-			isPackageIncluded = true
-			buffer.WriteString(line)
-			continue
-		}
-		if strings.Contains(line, "func init() {") {
-			buffer.WriteString(line)
-			isInsideInit++
-			continue
-		}
-
-		if isInsideInit == 1 {
-			if strings.HasPrefix(line, "}") {
-				buffer.WriteString(line)
-				isInsideInit++
-				continue
-			}
-		}
-
-		if isInsideInit == 1 {
-			if strings.Contains(line, "versioned.NewPackageManager") {
-				isPackageInitialized = true
-				buffer.WriteString(line)
-				continue
-			}
-
-			if isPackageInitialized {
-				verRegex := regexp.MustCompile("\\s*(\\S.*)\\.Set(\\S+)\\(\\S+, \"(.*)\"")
-
-				if m := verRegex.FindStringSubmatch(line); len(m) > 0 {
-					isRepl := false
-					repl := ""
-					switch v := m[2]; v {
-					case "Version":
-						foundVersionMatch = true
-						if m[3] != pkg.Version {
-							repl = pkg.Version
-							isRepl = true
-						}
-					case "GitBranch":
-						if m[3] != pkg.Git.Branch {
-							repl = pkg.Git.Branch
-							isRepl = true
-						}
-						if isPreRelease {
-							repl = ""
-							isRepl = true
-						}
-					case "GitCommit":
-						if m[3] != pkg.Git.Commit {
-							repl = pkg.Git.Commit
-							isRepl = true
-						}
-						if isPreRelease {
-							repl = pkg.Version
-							isRepl = true
-						}
-					default:
-						// do nothing
-					}
-
-					if isRepl {
-						line = strings.Replace(line, "\""+m[3]+"\"", "\""+repl+"\"", -1)
-						rewrite = true
-					}
-				}
-			}
-		}
-		buffer.WriteString(line)
-
-		//buffer.WriteString(strings.TrimSpace(line))
-	}
-
-	// fmt.Fprintf(os.Stderr, "%s\n", buffer.String())
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-
-	fh.Close()
-
-	ref := "Please see https://github.com/greenpau/versioned#package-metadata"
-
-	if !isPackageIncluded {
-		return fmt.Errorf("package %s not found", pkgName)
-	}
-
-	if !isPackageInitialized {
-		return fmt.Errorf("package %s is not initialized. %s", pkgName, ref)
-	}
-
-	if !foundVersionMatch {
-		return fmt.Errorf("package version not found. %s", ref)
-	}
-
-	if rewrite {
-		return ioutil.WriteFile(fp, buffer.Bytes(), 0644)
-	}
-	return nil
+// printDeprecation prints the subcommand replacing the deprecated flag.
+func printDeprecation(flagName, cmd string) {
+	fmt.Fprintf(os.Stderr, "warning: -%s flag is deprecated, use \"%s %s\" instead\n", flagName, app.Name, cmd)
 }
 
 func executeShell(args []string) (string, error) {
@@ -605,58 +226,14 @@ func executeShell(args []string) (string, error) {
 	return strings.Split(stdout.String(), "\n")[0], nil
 }
 
-func exitWithError(err interface{}) {
-	fmt.Fprintf(os.Stderr, "%s\n", err)
-	os.Exit(1)
-}
-
-// syncPackageJSON updates the version field in a Node.js package.json file.
-func syncPackageJSON(pkg *versioned.PackageManager, fp string, fi os.FileInfo) error {
-	var buffer bytes.Buffer
-	fh, err := os.Open(fp)
-	if err != nil {
-		return err
-	}
-	defer fh.Close()
-
-	isVersionFound := false
-	fileVersion := ""
-	// Regex to match "version": "1.2.3" with potential spaces/tabs
-	versionRegex := regexp.MustCompile(`(?i)^(\s*"version"\s*:\s*")([^"]+)("\s*,?\s*)$`)
-
-	scanner := bufio.NewScanner(fh)
-	for scanner.Scan() {
-		line := scanner.Text()
-		matches := versionRegex.FindStringSubmatch(line)
-
-		if len(matches) == 4 {
-			isVersionFound = true
-			prefix := matches[1]
-			fileVersion = matches[2]
-			suffix := matches[3]
-
-			if fileVersion != pkg.Version {
-				buffer.WriteString(prefix + pkg.Version + suffix + "\n")
-			} else {
-				buffer.WriteString(line + "\n")
-			}
-			continue
-		}
-		buffer.WriteString(line + "\n")
-	}
-
-	if err := scanner.Err(); err != nil {
-		return err
-	}
-	fh.Close()
+// stringList is a flag.Value collecting the values of a repeated flag.
+type stringList []string
 
-	if !isVersionFound {
-		return fmt.Errorf("version field not found in %s", fp)
-	}
-
-	if pkg.Version == fileVersion {
-		return nil
-	}
+func (s *stringList) String() string {
+	return strings.Join(*s, ", ")
+}
 
-	return os.WriteFile(fp, buffer.Bytes(), fi.Mode().Perm())
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
 }
@@ -0,0 +1,166 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/greenpau/versioned"
+)
+
+var (
+	// outputFormat is the format of the command output, i.e. text, json,
+	// or yaml.
+	outputFormat = "text"
+	result       = &commandResult{}
+	// snapshot holds the contents of the files the command may change.
+	snapshot = make(fileSnapshot)
+)
+
+// commandResult is the result of a command printed with json or yaml
+// output format.
+type commandResult struct {
+	Command      string                  `json:"command" yaml:"command"`
+	Version      string                  `json:"version,omitempty" yaml:"version,omitempty"`
+	OldVersion   string                  `json:"old_version,omitempty" yaml:"old_version,omitempty"`
+	NewVersion   string                  `json:"new_version,omitempty" yaml:"new_version,omitempty"`
+	FilesChanged []string                `json:"files_changed" yaml:"files_changed"`
+	License      *licenseResult          `json:"license,omitempty" yaml:"license,omitempty"`
+	Documents    []*versioned.Document   `json:"documents,omitempty" yaml:"documents,omitempty"`
+	Warnings     []string                `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	BrokenLinks  []*versioned.BrokenLink `json:"broken_links,omitempty" yaml:"broken_links,omitempty"`
+	Dependencies []*versioned.Dependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Ldflags      string                  `json:"ldflags,omitempty" yaml:"ldflags,omitempty"`
+	Error        string                  `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// licenseResult is the license of the file added, written, stripped, or
+// checked by a command.
+type licenseResult struct {
+	FilePath         string   `json:"file_path" yaml:"file_path"`
+	Type             string   `json:"type" yaml:"type"`
+	CopyrightHolders []string `json:"copyright_holders,omitempty" yaml:"copyright_holders,omitempty"`
+	Year             uint64   `json:"year,omitempty" yaml:"year,omitempty"`
+}
+
+func exitWithError(err interface{}) {
+	if isStructuredOutput() {
+		result.Error = fmt.Sprintf("%s", err)
+		writeResult()
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", err)
+	os.Exit(1)
+}
+
+// exitWithResult prints the result of the command in json or yaml output
+// format and exits.
+func exitWithResult() {
+	if isStructuredOutput() {
+		writeResult()
+	}
+	os.Exit(0)
+}
+
+// setOutputFormat sets the output format, i.e. text, json, or yaml. The
+// progress messages are not printed in json and yaml output formats.
+func setOutputFormat(s string) error {
+	switch s {
+	case "text", "json", "yaml":
+		outputFormat = s
+		return nil
+	}
+	return fmt.Errorf("output format %q is unsupported", s)
+}
+
+func isStructuredOutput() bool {
+	return outputFormat == "json" || outputFormat == "yaml"
+}
+
+func writeResult() {
+	result.FilesChanged = snapshot.changed()
+	b, err := versioned.Encode(result, outputFormat)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(b)
+}
+
+// printWarnings prints the warnings to stderr in text output format.
+func printWarnings(warnings []string) {
+	if isStructuredOutput() {
+		return
+	}
+	for _, s := range warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", s)
+	}
+}
+
+// printBrokenLinks prints the broken links to stderr in text output
+// format.
+func printBrokenLinks(links []*versioned.BrokenLink) {
+	if isStructuredOutput() {
+		return
+	}
+	for _, l := range links {
+		fmt.Fprintf(os.Stderr, "%s\n", l)
+	}
+}
+
+// fileSnapshot holds the contents of the files, by path, before a command
+// runs.
+type fileSnapshot map[string][]byte
+
+// add reads the files and the files in the directories, unless they are
+// in the snapshot already. The contents of the missing files are empty.
+func (s fileSnapshot) add(paths ...string) {
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		filepath.Walk(p, func(fp string, fi os.FileInfo, err error) error {
+			if _, exists := s[fp]; exists {
+				return nil
+			}
+			if err != nil {
+				s[fp] = nil
+				return nil
+			}
+			if fi.Mode().IsRegular() {
+				s[fp], _ = ioutil.ReadFile(fp)
+			}
+			return nil
+		})
+	}
+}
+
+// changed returns the sorted paths of the files whose contents changed.
+func (s fileSnapshot) changed() []string {
+	files := []string{}
+	for fp, b := range s {
+		current, _ := ioutil.ReadFile(fp)
+		if !bytes.Equal(b, current) {
+			files = append(files, fp)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
@@ -0,0 +1,338 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var licenseSPDX = map[string]string{
+	"apache":    "Apache-2.0",
+	"asl":       "LicenseRef-ASL",
+	"mit":       "MIT",
+	"gpl3":      "GPL-3.0",
+	"gpl2":      "GPL-2.0",
+	"agpl3":     "AGPL-3.0",
+	"lgpl3":     "LGPL-3.0",
+	"lgpl21":    "LGPL-2.1",
+	"mpl2":      "MPL-2.0",
+	"bsd3":      "BSD-3-Clause",
+	"bsd2":      "BSD-2-Clause",
+	"isc":       "ISC",
+	"unlicense": "Unlicense",
+}
+
+// Dependency is a Go module dependency and its license.
+type Dependency struct {
+	Path        string `json:"path" xml:"path" yaml:"path"`
+	Version     string `json:"version" xml:"version" yaml:"version"`
+	Indirect    bool   `json:"indirect" xml:"indirect" yaml:"indirect"`
+	License     string `json:"license" xml:"license" yaml:"license"`
+	SPDX        string `json:"spdx" xml:"spdx" yaml:"spdx"`
+	LicenseFile string `json:"license_file" xml:"license_file" yaml:"license_file"`
+	Denied      bool   `json:"denied" xml:"denied" yaml:"denied"`
+	dir         string
+}
+
+// DependencyReport is a license report for the dependencies of a Go module.
+type DependencyReport struct {
+	ModFilePath  string
+	ModCacheDir  string
+	DenyList     []string
+	Dependencies []*Dependency
+}
+
+// NewDependencyReport returns an instance of DependencyReport.
+func NewDependencyReport() *DependencyReport {
+	return &DependencyReport{
+		ModFilePath: "go.mod",
+		ModCacheDir: getModCacheDir(),
+	}
+}
+
+// AddFilePath adds the path to go.mod file.
+func (r *DependencyReport) AddFilePath(fp string) error {
+	if fp == "" {
+		return fmt.Errorf("file path is empty")
+	}
+	r.ModFilePath = fp
+	return nil
+}
+
+// AddDeniedLicense adds a license type, e.g. agpl3, or SPDX identifier,
+// e.g. AGPL-3.0, to the deny list.
+func (r *DependencyReport) AddDeniedLicense(s string) error {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return fmt.Errorf("denied license is empty")
+	}
+	r.DenyList = append(r.DenyList, s)
+	return nil
+}
+
+// Denied returns the dependencies having a license on the deny list.
+func (r *DependencyReport) Denied() []*Dependency {
+	var deps []*Dependency
+	for _, dep := range r.Dependencies {
+		if dep.Denied {
+			deps = append(deps, dep)
+		}
+	}
+	return deps
+}
+
+// ScanDependencies reads go.mod file and detects the license of each
+// dependency. The dependencies are looked up in vendor/ directory, when
+// the module is vendored, or in the module cache. It does not download
+// missing modules.
+func ScanDependencies(r *DependencyReport) error {
+	deps, replacements, err := parseModFile(r.ModFilePath)
+	if err != nil {
+		return err
+	}
+	modDir := filepath.Dir(r.ModFilePath)
+	vendorDir := filepath.Join(modDir, "vendor")
+	isVendored := false
+	if _, err := os.Stat(filepath.Join(vendorDir, "modules.txt")); err == nil {
+		isVendored = true
+	}
+
+	for _, dep := range deps {
+		switch {
+		case isVendored:
+			dep.dir = filepath.Join(vendorDir, filepath.FromSlash(dep.Path))
+		case replacements[dep.Path] != nil:
+			repl := replacements[dep.Path]
+			if repl.Version == "" {
+				dep.dir = repl.Path
+				if !filepath.IsAbs(dep.dir) {
+					dep.dir = filepath.Join(modDir, dep.dir)
+				}
+				break
+			}
+			dep.dir = filepath.Join(r.ModCacheDir, escapeModulePath(repl.Path)+"@"+escapeModulePath(repl.Version))
+		default:
+			dep.dir = filepath.Join(r.ModCacheDir, escapeModulePath(dep.Path)+"@"+escapeModulePath(dep.Version))
+		}
+
+		dep.License = "unknown"
+		fp, licenseType := findLicenseFile(dep.dir)
+		if fp != "" {
+			dep.LicenseFile = fp
+		}
+		if licenseType != "" {
+			dep.License = licenseType
+			dep.SPDX = licenseSPDX[licenseType]
+		}
+		for _, s := range r.DenyList {
+			if strings.EqualFold(s, dep.License) || strings.EqualFold(s, dep.SPDX) {
+				dep.Denied = true
+			}
+		}
+	}
+	sort.Slice(deps, func(i, j int) bool { return deps[i].Path < deps[j].Path })
+	r.Dependencies = deps
+	return nil
+}
+
+// Render returns the report in markdown, csv, or json format.
+func (r *DependencyReport) Render(format string) ([]byte, error) {
+	var b bytes.Buffer
+	switch format {
+	case "markdown", "md", "":
+		b.WriteString("| Module | Version | License | Indirect | Denied |\n")
+		b.WriteString("| --- | --- | --- | --- | --- |\n")
+		for _, dep := range r.Dependencies {
+			license := dep.License
+			if dep.SPDX != "" {
+				license = dep.SPDX
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %t | %t |\n", dep.Path, dep.Version, license, dep.Indirect, dep.Denied)
+		}
+	case "csv":
+		w := csv.NewWriter(&b)
+		w.Write([]string{"path", "version", "indirect", "license", "spdx", "license_file", "denied"})
+		for _, dep := range r.Dependencies {
+			w.Write([]string{
+				dep.Path, dep.Version, fmt.Sprintf("%t", dep.Indirect),
+				dep.License, dep.SPDX, dep.LicenseFile, fmt.Sprintf("%t", dep.Denied),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			return nil, err
+		}
+	case "json":
+		deps := r.Dependencies
+		if deps == nil {
+			deps = []*Dependency{}
+		}
+		out, err := json.MarshalIndent(deps, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		b.Write(out)
+		b.WriteString("\n")
+	default:
+		return nil, fmt.Errorf("report format %q is unsupported", format)
+	}
+	return b.Bytes(), nil
+}
+
+// parseModFile returns the required modules and the replacements of
+// the modules found in go.mod file.
+func parseModFile(fp string) ([]*Dependency, map[string]*Dependency, error) {
+	fh, err := os.Open(fp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed opening file %q: %v", fp, err)
+	}
+	defer fh.Close()
+
+	var deps []*Dependency
+	replacements := make(map[string]*Dependency)
+	var block string
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var comment string
+		if i := strings.Index(line, "//"); i >= 0 {
+			comment = strings.TrimSpace(line[i+2:])
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if block != "" && fields[0] == ")" {
+			block = ""
+			continue
+		}
+		directive := block
+		if directive == "" {
+			directive = fields[0]
+			if directive != "require" && directive != "replace" {
+				continue
+			}
+			fields = fields[1:]
+			if len(fields) == 1 && fields[0] == "(" {
+				block = directive
+				continue
+			}
+		}
+		switch directive {
+		case "require":
+			if len(fields) < 2 {
+				return nil, nil, fmt.Errorf("malformed require directive in %q: %s", fp, line)
+			}
+			deps = append(deps, &Dependency{
+				Path:     unquoteModulePath(fields[0]),
+				Version:  fields[1],
+				Indirect: comment == "indirect",
+			})
+		case "replace":
+			// The directive is "old [version] => new [version]".
+			i := 0
+			for i < len(fields) && fields[i] != "=>" {
+				i++
+			}
+			if i == 0 || i == len(fields)-1 || i == len(fields) {
+				return nil, nil, fmt.Errorf("malformed replace directive in %q: %s", fp, line)
+			}
+			repl := &Dependency{Path: unquoteModulePath(fields[i+1])}
+			if len(fields) > i+2 {
+				repl.Version = fields[i+2]
+			}
+			replacements[unquoteModulePath(fields[0])] = repl
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return deps, replacements, nil
+}
+
+func unquoteModulePath(s string) string {
+	return strings.Trim(s, "\"`")
+}
+
+// escapeModulePath escapes a module path or version the way the module
+// cache does, i.e. an upper-case letter becomes an exclamation mark
+// followed by the letter's lower-case equivalent.
+func escapeModulePath(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if unicode.IsUpper(c) {
+			sb.WriteRune('!')
+			sb.WriteRune(unicode.ToLower(c))
+			continue
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
+
+func getModCacheDir() string {
+	if s := os.Getenv("GOMODCACHE"); s != "" {
+		return s
+	}
+	if s := os.Getenv("GOPATH"); s != "" {
+		return filepath.Join(filepath.SplitList(s)[0], "pkg", "mod")
+	}
+	if s, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(s, "go", "pkg", "mod")
+	}
+	return ""
+}
+
+// findLicenseFile returns the path to the license file in a directory
+// and its license type.
+func findLicenseFile(dir string) (string, string) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return "", ""
+	}
+	var candidate string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := strings.ToUpper(entry.Name())
+		if !strings.HasPrefix(name, "LICENSE") && !strings.HasPrefix(name, "LICENCE") && !strings.HasPrefix(name, "COPYING") {
+			continue
+		}
+		fp := filepath.Join(dir, entry.Name())
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			continue
+		}
+		if licenseType := detectLicenseType(b); licenseType != "" {
+			return fp, licenseType
+		}
+		if candidate == "" {
+			candidate = fp
+		}
+	}
+	return candidate, ""
+}
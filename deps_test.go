@@ -0,0 +1,120 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testGoMod = `module example.com/app
+
+go 1.25
+
+require (
+	example.com/Foo v1.0.0
+	example.com/bar v1.2.0 // indirect
+	"example.com/baz" v0.1.0
+)
+
+require example.com/qux v2.0.0+incompatible
+
+replace example.com/qux => ./third_party/qux
+`
+
+func writeTestFile(t *testing.T, fp, s string) {
+	if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fp, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanDependencies(t *testing.T) {
+	mit, err := ioutil.ReadFile("LICENSE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	agpl := "GNU AFFERO GENERAL PUBLIC LICENSE\n   Version 3, 19 November 2007\n"
+	bsd := "Redistribution and use in source and binary forms, with or without\nmodification, are permitted.\n"
+
+	for _, vendored := range []bool{false, true} {
+		dir := t.TempDir()
+		cacheDir := filepath.Join(dir, "cache")
+		writeTestFile(t, filepath.Join(dir, "go.mod"), testGoMod)
+		writeTestFile(t, filepath.Join(dir, "third_party", "qux", "COPYING"), bsd)
+		if vendored {
+			writeTestFile(t, filepath.Join(dir, "vendor", "modules.txt"), "")
+			writeTestFile(t, filepath.Join(dir, "vendor", "example.com", "Foo", "LICENSE"), string(mit))
+			writeTestFile(t, filepath.Join(dir, "vendor", "example.com", "bar", "LICENSE.md"), agpl)
+			writeTestFile(t, filepath.Join(dir, "vendor", "example.com", "qux", "LICENSE"), bsd)
+		} else {
+			writeTestFile(t, filepath.Join(cacheDir, "example.com", "!foo@v1.0.0", "LICENSE"), string(mit))
+			writeTestFile(t, filepath.Join(cacheDir, "example.com", "bar@v1.2.0", "LICENSE.md"), agpl)
+		}
+
+		r := NewDependencyReport()
+		r.ModCacheDir = cacheDir
+		if err := r.AddFilePath(filepath.Join(dir, "go.mod")); err != nil {
+			t.Fatal(err)
+		}
+		if err := r.AddDeniedLicense("AGPL-3.0"); err != nil {
+			t.Fatal(err)
+		}
+		if err := ScanDependencies(r); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, dep := range r.Dependencies {
+			got = append(got, strings.Join([]string{dep.Path, dep.Version, dep.License}, " "))
+		}
+		expected := []string{
+			"example.com/Foo v1.0.0 mit",
+			"example.com/bar v1.2.0 agpl3",
+			"example.com/baz v0.1.0 unknown",
+			"example.com/qux v2.0.0+incompatible bsd2",
+		}
+		if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+			t.Fatalf("FAIL: vendored %t: dependencies mismatch:\n>>>got:\n%s\n>>>expected:\n%s",
+				vendored, strings.Join(got, "\n"), strings.Join(expected, "\n"))
+		}
+		if denied := r.Denied(); len(denied) != 1 || denied[0].Path != "example.com/bar" {
+			t.Fatalf("FAIL: vendored %t: expected example.com/bar to be denied, got %v", vendored, denied)
+		}
+		if !r.Dependencies[1].Indirect {
+			t.Fatalf("FAIL: vendored %t: expected example.com/bar to be indirect", vendored)
+		}
+
+		for _, format := range []string{"markdown", "csv", "json"} {
+			b, err := r.Render(format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(b), "AGPL-3.0") {
+				t.Fatalf("FAIL: vendored %t: %s report has no AGPL-3.0 license:\n%s", vendored, format, b)
+			}
+		}
+	}
+
+	r := NewDependencyReport()
+	if _, err := r.Render("xml"); err == nil {
+		t.Fatal("FAIL: expected unsupported format error, got success")
+	}
+}
@@ -0,0 +1,352 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	docsIndexBeginMarker = "<!-- begin-docs-index -->"
+	docsIndexEndMarker   = "<!-- end-docs-index -->"
+)
+
+// DocumentationSet is a directory of Markdown documents, e.g. docs/, with
+// an index file linking the documents.
+type DocumentationSet struct {
+	Dir       string
+	IndexFile string
+	Title     string
+	// Order is the list of the documents, relative to the directory,
+	// listed first in the index. The other documents follow in
+	// alphabetical order.
+	Order []string
+	// Toc is the configuration of the tables of contents of the documents.
+	Toc         *TableOfContents
+	Documents   []*Document
+	BrokenLinks []*BrokenLink
+	// Warnings are the warnings of the table of contents updates in
+	// lenient mode.
+	Warnings []string
+}
+
+// Document is a Markdown document of a documentation set.
+type Document struct {
+	// Path is the path to the document relative to the directory of
+	// the documentation set, with forward slashes.
+	Path     string             `json:"path" xml:"path" yaml:"path"`
+	Title    string             `json:"title" xml:"title" yaml:"title"`
+	Headings []*DocumentHeading `json:"headings" xml:"headings" yaml:"headings"`
+}
+
+// DocumentHeading is a top-level heading of a document.
+type DocumentHeading struct {
+	Title string `json:"title" xml:"title" yaml:"title"`
+	Link  string `json:"link" xml:"link" yaml:"link"`
+}
+
+// NewDocumentationSet returns an instance of DocumentationSet.
+func NewDocumentationSet() *DocumentationSet {
+	return &DocumentationSet{
+		Dir:       "docs",
+		IndexFile: "index.md",
+		Title:     "Documentation",
+		Toc:       NewTableOfContents(),
+	}
+}
+
+// AddDir adds the directory with the documents.
+func (d *DocumentationSet) AddDir(s string) error {
+	if s == "" {
+		return fmt.Errorf("documentation directory is empty")
+	}
+	d.Dir = s
+	return nil
+}
+
+// AddIndexFile adds the name of the index file, relative to the directory.
+func (d *DocumentationSet) AddIndexFile(s string) error {
+	if s == "" {
+		return fmt.Errorf("documentation index file is empty")
+	}
+	d.IndexFile = s
+	return nil
+}
+
+// AddTitle adds the title of the index file.
+func (d *DocumentationSet) AddTitle(s string) {
+	if s == "" {
+		return
+	}
+	d.Title = s
+}
+
+// AddOrder adds the documents listed first in the index.
+func (d *DocumentationSet) AddOrder(arr ...string) {
+	for _, s := range arr {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		d.Order = append(d.Order, filepath.ToSlash(filepath.Clean(s)))
+	}
+}
+
+// UpdateDocs updates the table of contents of each document in the
+// directory of the documentation set and the index of the documents.
+// Then, it checks that the relative links in the documents point to
+// existing files and headings.
+func UpdateDocs(d *DocumentationSet) error {
+	fi, err := os.Stat(d.Dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("path %q is not a directory", d.Dir)
+	}
+
+	paths, err := d.getDocumentPaths()
+	if err != nil {
+		return err
+	}
+
+	d.Documents = nil
+	d.Warnings = nil
+	for _, p := range paths {
+		fp := filepath.Join(d.Dir, filepath.FromSlash(p))
+		toc := d.Toc.clone(fp)
+		if err := UpdateToc(toc); err != nil {
+			return fmt.Errorf("failed updating %q: %v", fp, err)
+		}
+		d.Warnings = append(d.Warnings, toc.Warnings...)
+		doc := &Document{
+			Path:  p,
+			Title: strings.TrimSuffix(filepath.Base(p), filepath.Ext(p)),
+		}
+		lines, err := readLines(fp)
+		if err != nil {
+			return err
+		}
+		for _, h := range parseMarkdown(lines).headings {
+			if h.level == 1 && h.title != "" {
+				doc.Title = h.title
+				break
+			}
+		}
+		for _, entry := range toc.entries {
+			if entry.depth != toc.minDepth {
+				continue
+			}
+			doc.Headings = append(doc.Headings, &DocumentHeading{
+				Title: entry.title,
+				Link:  p + entry.link,
+			})
+		}
+		d.Documents = append(d.Documents, doc)
+	}
+
+	if err := d.updateIndex(); err != nil {
+		return err
+	}
+
+	c := NewLinkCheck()
+	c.RootDir = d.Dir
+	if err := c.AddSlugStyle(d.Toc.SlugStyle); err != nil {
+		return err
+	}
+	for _, p := range append(paths, d.IndexFile) {
+		if err := c.AddFilePath(filepath.Join(d.Dir, filepath.FromSlash(p))); err != nil {
+			return err
+		}
+	}
+	err = CheckLinks(c)
+	d.BrokenLinks = c.BrokenLinks
+	return err
+}
+
+// getDocumentPaths returns the paths to the Markdown documents, except the
+// index file, relative to the directory and in the configured order.
+func (d *DocumentationSet) getDocumentPaths() ([]string, error) {
+	index := filepath.ToSlash(filepath.Clean(d.IndexFile))
+	var paths []string
+	err := filepath.Walk(d.Dir, func(fp string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() {
+			if fp != d.Dir && strings.HasPrefix(fi.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(fp)) {
+		case ".md", ".markdown":
+		default:
+			return nil
+		}
+		p, err := filepath.Rel(d.Dir, fp)
+		if err != nil {
+			return err
+		}
+		if p = filepath.ToSlash(p); p != index {
+			paths = append(paths, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	rank := make(map[string]int)
+	for i, p := range d.Order {
+		rank[p] = i + 1
+	}
+	found := make(map[string]bool)
+	for _, p := range paths {
+		found[p] = true
+	}
+	for _, p := range d.Order {
+		if !found[p] {
+			return nil, fmt.Errorf("ordered document %q not found in %q", p, d.Dir)
+		}
+	}
+	sort.SliceStable(paths, func(i, j int) bool {
+		ri, rj := rank[paths[i]], rank[paths[j]]
+		switch {
+		case ri > 0 && rj > 0:
+			return ri < rj
+		case ri > 0:
+			return true
+		}
+		return false
+	})
+	return paths, nil
+}
+
+// updateIndex writes the list of the documents between the markers of the
+// index file. When the index file has no markers, the list is appended to
+// the file. When the file does not exist, it gets created.
+func (d *DocumentationSet) updateIndex() error {
+	block := []string{docsIndexBeginMarker}
+	for _, doc := range d.Documents {
+		link, err := d.getIndexLink(doc.Path)
+		if err != nil {
+			return err
+		}
+		block = append(block, fmt.Sprintf("* [%s](%s)", escapeLinkText(doc.Title), link))
+		for _, h := range doc.Headings {
+			anchor := strings.TrimPrefix(h.Link, doc.Path)
+			block = append(block, fmt.Sprintf("  * [%s](%s)", escapeLinkText(h.Title), link+anchor))
+		}
+	}
+	block = append(block, docsIndexEndMarker)
+
+	fp := filepath.Join(d.Dir, d.IndexFile)
+	var mode os.FileMode = 0644
+	var b []byte
+	var lines []string
+	if fi, err := os.Stat(fp); err == nil {
+		if !fi.Mode().IsRegular() {
+			return fmt.Errorf("path %q is not a file", fp)
+		}
+		mode = fi.Mode().Perm()
+		if b, err = ioutil.ReadFile(fp); err != nil {
+			return err
+		}
+		if lines, err = readLines(fp); err != nil {
+			return err
+		}
+	} else {
+		if err := os.MkdirAll(filepath.Dir(fp), 0755); err != nil {
+			return err
+		}
+		lines = []string{"# " + d.Title, ""}
+	}
+
+	beginIndex, endIndex := -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case docsIndexBeginMarker:
+			beginIndex = i
+		case docsIndexEndMarker:
+			if beginIndex >= 0 {
+				endIndex = i
+			}
+		}
+	}
+
+	var output []string
+	switch {
+	case beginIndex >= 0 && endIndex < 0:
+		return fmt.Errorf("failed to find %q marker in %q", docsIndexEndMarker, fp)
+	case beginIndex >= 0:
+		output = append(output, lines[:beginIndex]...)
+		output = append(output, block...)
+		output = append(output, lines[endIndex+1:]...)
+	default:
+		output = append(output, lines...)
+		if len(output) > 0 && strings.TrimSpace(output[len(output)-1]) != "" {
+			output = append(output, "")
+		}
+		output = append(output, block...)
+	}
+
+	fileBuffer := []byte(strings.Join(output, "\n") + "\n")
+	if bytes.Equal(fileBuffer, b) {
+		return nil
+	}
+	return ioutil.WriteFile(fp, fileBuffer, mode)
+}
+
+// getIndexLink returns the link to a document, relative to the directory
+// of the documentation set, from the index file. The path segments are
+// percent-encoded, e.g. "my guide.md" becomes "my%20guide.md".
+func (d *DocumentationSet) getIndexLink(p string) (string, error) {
+	rel, err := filepath.Rel(filepath.Dir(filepath.Clean(d.IndexFile)), filepath.FromSlash(p))
+	if err != nil {
+		return "", err
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// escapeLinkText escapes the brackets in the text of a Markdown link.
+func escapeLinkText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`).Replace(s)
+}
+
+// readLines returns the lines of a file.
+func readLines(fp string) ([]string, error) {
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return nil, err
+	}
+	s := strings.TrimSuffix(strings.ReplaceAll(string(b), "\r\n", "\n"), "\n")
+	if s == "" {
+		return nil, nil
+	}
+	return strings.Split(s, "\n"), nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateDocs(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "install.md"), strings.Join([]string{
+		"# Installation",
+		"",
+		"See [usage](guide/usage.md#options) and [API](/api.md).",
+		"",
+		"## Linux",
+		"",
+		"### Packages",
+		"",
+		"## Windows",
+		"",
+	}, "\n"))
+	writeTestFile(t, filepath.Join(dir, "guide", "usage.md"), strings.Join([]string{
+		"# Usage",
+		"",
+		"Back to [index](../index.md), see [FAQ](faq.md) or [site](https://example.com/x.md).",
+		"",
+		"```",
+		"[ignored](missing.md)",
+		"```",
+		"",
+		"## Options",
+		"",
+		"[ref]: ./gone.md",
+		"",
+	}, "\n"))
+	writeTestFile(t, filepath.Join(dir, "api.md"), "No headings.\n")
+
+	d := NewDocumentationSet()
+	if err := d.AddDir(dir); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	d.AddOrder("install.md")
+	err := UpdateDocs(d)
+	if err == nil || err.Error() != "found 2 broken links" {
+		t.Fatalf("FAIL: unexpected error: %v", err)
+	}
+
+	var brokenLinks []string
+	for _, l := range d.BrokenLinks {
+		brokenLinks = append(brokenLinks, l.String())
+	}
+	expected := []string{
+		filepath.Join(dir, "guide", "usage.md") + `:3: broken link to "faq.md": file not found`,
+		filepath.Join(dir, "guide", "usage.md") + `:18: broken link to "./gone.md": file not found`,
+	}
+	if strings.Join(brokenLinks, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("FAIL: broken links mismatch:\n%s\n(actual) vs.\n%s\n(expected)", strings.Join(brokenLinks, "\n"), strings.Join(expected, "\n"))
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	index := strings.Join([]string{
+		"# Documentation",
+		"",
+		"<!-- begin-docs-index -->",
+		"* [Installation](install.md)",
+		"  * [Linux](install.md#linux)",
+		"  * [Windows](install.md#windows)",
+		"* [api](api.md)",
+		"* [Usage](guide/usage.md)",
+		"  * [Options](guide/usage.md#options)",
+		"<!-- end-docs-index -->",
+		"",
+	}, "\n")
+	if string(b) != index {
+		t.Fatalf("FAIL: index mismatch:\n%s\n(actual) vs.\n%s\n(expected)", b, index)
+	}
+
+	b, err = ioutil.ReadFile(filepath.Join(dir, "install.md"))
+	if err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if !strings.Contains(string(b), "* [Linux](#linux)\n  * [Packages](#packages)\n* [Windows](#windows)\n") {
+		t.Fatalf("FAIL: table of contents not found in install.md:\n%s", b)
+	}
+
+	// The index is updated between the markers.
+	writeTestFile(t, filepath.Join(dir, "index.md"), "# Docs\n\nIntro.\n\n<!-- begin-docs-index -->\n<!-- end-docs-index -->\n\nFooter.\n")
+	d.AddOrder("guide/usage.md")
+	if err := UpdateDocs(d); err == nil {
+		t.Fatalf("FAIL: expected broken links error")
+	}
+	b, err = ioutil.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if !strings.HasPrefix(string(b), "# Docs\n\nIntro.\n\n<!-- begin-docs-index -->\n* [Installation](install.md)\n  * [Linux](install.md#linux)\n  * [Windows](install.md#windows)\n* [Usage](guide/usage.md)\n") ||
+		!strings.HasSuffix(string(b), "<!-- end-docs-index -->\n\nFooter.\n") {
+		t.Fatalf("FAIL: index mismatch:\n%s", b)
+	}
+
+	d.AddOrder("missing.md")
+	if err := UpdateDocs(d); err == nil || !strings.Contains(err.Error(), "missing.md") {
+		t.Fatalf("FAIL: expected ordered document error, got: %v", err)
+	}
+
+	// The links of the index file in a subdirectory are relative to it,
+	// and the file names with spaces are percent-encoded.
+	dir = t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "my guide.md"), "# My [Beta] Guide\n\n## Setup\n")
+	writeTestFile(t, filepath.Join(dir, "api.md"), "No headings.\n")
+	d = NewDocumentationSet()
+	if err := d.AddDir(dir); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if err := d.AddIndexFile("sub/index.md"); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if err := UpdateDocs(d); err != nil {
+		t.Fatalf("FAIL: unexpected error: %v", err)
+	}
+	b, err = ioutil.ReadFile(filepath.Join(dir, "sub", "index.md"))
+	if err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	index = strings.Join([]string{
+		"# Documentation",
+		"",
+		"<!-- begin-docs-index -->",
+		"* [api](../api.md)",
+		"* [My \\[Beta\\] Guide](../my%20guide.md)",
+		"  * [Setup](../my%20guide.md#setup)",
+		"<!-- end-docs-index -->",
+		"",
+	}, "\n")
+	if string(b) != index {
+		t.Fatalf("FAIL: index mismatch:\n%s\n(actual) vs.\n%s\n(expected)", b, index)
+	}
+}
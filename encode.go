@@ -0,0 +1,273 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	reYAMLPlain     = regexp.MustCompile(`^[A-Za-z0-9_/][A-Za-z0-9_./@+=,()' -]*$`)
+	reYAMLTimestamp = regexp.MustCompile(`^\d{4}-\d\d?-\d\d?`)
+)
+
+// Encode returns the value, e.g. PackageManager, in json or yaml format.
+// The keys are the names in the "json" and "yaml" struct tags.
+func Encode(v interface{}, format string) ([]byte, error) {
+	switch format {
+	case "json":
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	case "yaml", "yml":
+		var b bytes.Buffer
+		if err := writeYAML(&b, reflect.ValueOf(v), ""); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}
+	return nil, fmt.Errorf("output format %q is unsupported", format)
+}
+
+type yamlField struct {
+	key   string
+	value reflect.Value
+}
+
+// writeYAML writes the value in block style, one key or list item per
+// line, with the provided indentation.
+func writeYAML(b *bytes.Buffer, v reflect.Value, indent string) error {
+	v = getYAMLValue(v)
+	switch {
+	case isYAMLMapping(v):
+		fields, err := getYAMLFields(v)
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			b.WriteString(indent + "{}\n")
+			return nil
+		}
+		for _, f := range fields {
+			b.WriteString(indent + f.key + ":")
+			if err := writeYAMLNested(b, f.value, indent+"  "); err != nil {
+				return err
+			}
+		}
+	case isYAMLSequence(v):
+		if v.Len() == 0 {
+			b.WriteString(indent + "[]\n")
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := getYAMLValue(v.Index(i))
+			if isYAMLMapping(item) || isYAMLSequence(item) {
+				// The first key of the mapping follows the dash.
+				var ib bytes.Buffer
+				if err := writeYAML(&ib, item, indent+"  "); err != nil {
+					return err
+				}
+				b.WriteString(indent + "- " + strings.TrimPrefix(ib.String(), indent+"  "))
+				continue
+			}
+			b.WriteString(indent + "-")
+			if err := writeYAMLNested(b, item, indent+"  "); err != nil {
+				return err
+			}
+		}
+	default:
+		s, err := getYAMLScalar(v)
+		if err != nil {
+			return err
+		}
+		b.WriteString(indent + s + "\n")
+	}
+	return nil
+}
+
+// writeYAMLNested writes the value following a key or a dash.
+func writeYAMLNested(b *bytes.Buffer, v reflect.Value, indent string) error {
+	v = getYAMLValue(v)
+	switch {
+	case isYAMLMapping(v):
+		fields, err := getYAMLFields(v)
+		if err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			b.WriteString(" {}\n")
+			return nil
+		}
+	case isYAMLSequence(v):
+		if v.Len() == 0 {
+			b.WriteString(" []\n")
+			return nil
+		}
+	default:
+		s, err := getYAMLScalar(v)
+		if err != nil {
+			return err
+		}
+		b.WriteString(" " + s + "\n")
+		return nil
+	}
+	b.WriteString("\n")
+	return writeYAML(b, v, indent)
+}
+
+// getYAMLValue dereferences pointers and interfaces. The value is invalid
+// when the pointer is nil.
+func getYAMLValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isYAMLMapping(v reflect.Value) bool {
+	return v.IsValid() && (v.Kind() == reflect.Struct || v.Kind() == reflect.Map)
+}
+
+func isYAMLSequence(v reflect.Value) bool {
+	if !v.IsValid() || (v.Kind() != reflect.Slice && v.Kind() != reflect.Array) {
+		return false
+	}
+	return v.Type().Elem().Kind() != reflect.Uint8
+}
+
+// getYAMLFields returns the keys and the values of a struct, in the order
+// of the fields, or of a map, in the order of the keys. The struct fields
+// are named by their "yaml" tags and honor the "omitempty" option.
+func getYAMLFields(v reflect.Value) ([]*yamlField, error) {
+	var fields []*yamlField
+	if v.Kind() == reflect.Map {
+		for _, k := range v.MapKeys() {
+			fields = append(fields, &yamlField{
+				key:   fmt.Sprint(k.Interface()),
+				value: v.MapIndex(k),
+			})
+		}
+		sort.Slice(fields, func(i, j int) bool {
+			return fields[i].key < fields[j].key
+		})
+		for _, f := range fields {
+			f.key = getYAMLString(f.key)
+		}
+		return fields, nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		name := strings.ToLower(sf.Name)
+		omitEmpty := false
+		if tag, exists := sf.Tag.Lookup("yaml"); exists {
+			if tag == "-" {
+				continue
+			}
+			opts := strings.Split(tag, ",")
+			if opts[0] != "" {
+				name = opts[0]
+			}
+			for _, opt := range opts[1:] {
+				if opt == "omitempty" {
+					omitEmpty = true
+				}
+			}
+		}
+		fv := v.Field(i)
+		if omitEmpty && isEmptyYAMLValue(fv) {
+			continue
+		}
+		fields = append(fields, &yamlField{key: getYAMLString(name), value: fv})
+	}
+	return fields, nil
+}
+
+func isEmptyYAMLValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
+
+// getYAMLScalar returns the string, number, or boolean value.
+func getYAMLScalar(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "null", nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return getYAMLString(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsNaN(f):
+			return ".nan", nil
+		case math.IsInf(f, 1):
+			return ".inf", nil
+		case math.IsInf(f, -1):
+			return "-.inf", nil
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	case reflect.Slice, reflect.Array:
+		// The byte slices are strings.
+		return getYAMLString(string(v.Bytes())), nil
+	}
+	return "", fmt.Errorf("yaml encoding of %s is unsupported", v.Type())
+}
+
+// getYAMLString returns the string in plain style when it is not mistaken
+// for another type, e.g. a number or a boolean, and in double-quoted style
+// otherwise.
+func getYAMLString(s string) string {
+	if !reYAMLPlain.MatchString(s) || strings.HasSuffix(s, " ") || reYAMLTimestamp.MatchString(s) {
+		return strconv.Quote(s)
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return strconv.Quote(s)
+	}
+	if _, err := strconv.ParseFloat(strings.ReplaceAll(s, "_", ""), 64); err == nil {
+		return strconv.Quote(s)
+	}
+	return s
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	pkg := NewPackageManager("versioned")
+	pkg.Version = "1.0.36"
+	pkg.Git.Commit = "v1.0.36-1-gabcdef0"
+	pkg.Git.Dirty = true
+	pkg.Build.GoVersion = "go1.22.1"
+	pkg.Dependencies = []*moduleDependency{{Path: "example.com/a", Version: "v1.0.0"}}
+	pkg.Build.OperatingSystem = "linux"
+	pkg.Build.Architecture = "amd64"
+	pkg.Build.Date = "2020-05-12T10:00:00Z"
+
+	type entry struct {
+		Name   string            `yaml:"name"`
+		Count  int               `yaml:"count,omitempty"`
+		Tags   []string          `yaml:"tags,omitempty"`
+		Labels map[string]string `yaml:"labels,omitempty"`
+		Skip   string            `yaml:"-"`
+		Plain  bool
+		hidden string
+	}
+
+	for i, test := range []struct {
+		input     interface{}
+		format    string
+		output    string
+		shouldErr bool
+	}{
+		{
+			input:  pkg,
+			format: "json",
+			output: `{
+  "name": "versioned",
+  "module": "",
+  "version": "1.0.36",
+  "tools_version": "",
+  "description": "",
+  "documentation": "",
+  "git": {
+    "branch": "",
+    "commit": "v1.0.36-1-gabcdef0",
+    "tag": "",
+    "commit_date": "",
+    "dirty": true
+  },
+  "build": {
+    "os": "linux",
+    "arch": "amd64",
+    "user": "",
+    "date": "2020-05-12T10:00:00Z",
+    "host": "",
+    "go_version": "go1.22.1",
+    "cgo": false
+  },
+  "dependencies": [
+    {
+      "path": "example.com/a",
+      "version": "v1.0.0"
+    }
+  ]
+}
+`,
+		},
+		{
+			input:  pkg,
+			format: "yaml",
+			output: `name: versioned
+module: ""
+version: 1.0.36
+tools_version: ""
+description: ""
+documentation: ""
+git:
+  branch: ""
+  commit: v1.0.36-1-gabcdef0
+  tag: ""
+  commit_date: ""
+  dirty: true
+build:
+  os: linux
+  arch: amd64
+  user: ""
+  date: "2020-05-12T10:00:00Z"
+  host: ""
+  go_version: go1.22.1
+  cgo: false
+dependencies:
+  - path: example.com/a
+    version: v1.0.0
+`,
+		},
+		{
+			input: []*entry{
+				{Name: "foo", Count: 2, Tags: []string{"true", "1.5", "a: b", "bar"}, Skip: "skip", hidden: "hidden"},
+				{Name: "2020-01-01", Labels: map[string]string{"z": "last", "a": "first"}, Plain: true},
+				nil,
+			},
+			format: "yaml",
+			output: `- name: foo
+  count: 2
+  tags:
+    - "true"
+    - "1.5"
+    - "a: b"
+    - bar
+  plain: false
+- name: "2020-01-01"
+  labels:
+    a: first
+    z: last
+  plain: true
+- null
+`,
+		},
+		{
+			input:  map[string][]int{"empty": {}, "list": {1, 2}},
+			format: "yaml",
+			output: `empty: []
+list:
+  - 1
+  - 2
+`,
+		},
+		{
+			input:     pkg,
+			format:    "toml",
+			shouldErr: true,
+		},
+		{
+			input:     map[string]func(){"f": nil},
+			format:    "yaml",
+			shouldErr: true,
+		},
+	} {
+		b, err := Encode(test.input, test.format)
+		if test.shouldErr {
+			if err == nil {
+				t.Fatalf("FAIL: test %d: expected error, but succeeded", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if string(b) != test.output {
+			t.Fatalf("FAIL: test %d: output mismatch:\n%s (actual)\n%s (expected)", i, b, test.output)
+		}
+	}
+}
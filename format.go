@@ -0,0 +1,171 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var reSectionNumber = regexp.MustCompile(`^\d+(\.\d+)*\\?\.\s+`)
+
+// documentFormat discovers the headings of a document and renders its
+// table of contents.
+type documentFormat interface {
+	// markers returns the default begin and end markers of the table of
+	// contents, followed by the markers of other tools.
+	markers() [][]string
+	// ignoreMarker returns the comment excluding a heading from the
+	// table of contents.
+	ignoreMarker() string
+	// ignoreMarkerSkipsBlank returns true when the blank lines may
+	// separate the ignore marker and the heading, e.g. the ones ending a
+	// comment. Otherwise, the marker is on the line right before the
+	// heading.
+	ignoreMarkerSkipsBlank() bool
+	// slugger returns the anchor generator of the format, or nil when
+	// the anchors follow the configured slug style.
+	slugger() Slugger
+	// hasTitleAnchor returns true when the title of the table of
+	// contents is a heading with an anchor.
+	hasTitleAnchor() bool
+	parse(lines []string) *parsedDocument
+	renderTitle(title string, level int) []string
+	// renderEntries returns the lines of either bulleted list, with the
+	// provided bullet, or ordered list.
+	renderEntries(entries []*tocEntry, minDepth int, sep string, ordered bool) []string
+	// setHeadingNumber writes the section number to the heading text,
+	// replacing the existing number.
+	setHeadingNumber(lines []string, h *parsedHeading, number string)
+}
+
+// getDocumentFormat returns the document format by its name, i.e.
+// markdown, rst, or asciidoc. When the name is empty, the format is
+// determined by the extension of the file.
+func getDocumentFormat(name, fp string) (documentFormat, error) {
+	if name == "" {
+		switch strings.ToLower(filepath.Ext(fp)) {
+		case ".rst", ".rest":
+			name = "rst"
+		case ".adoc", ".asciidoc", ".asc":
+			name = "asciidoc"
+		default:
+			name = "markdown"
+		}
+	}
+	switch name {
+	case "markdown", "md":
+		return markdownFormat{}, nil
+	case "rst", "restructuredtext":
+		return rstFormat{}, nil
+	case "asciidoc", "adoc":
+		return asciidocFormat{}, nil
+	}
+	return nil, fmt.Errorf("document format %q is unsupported", name)
+}
+
+// markdownFormat is Markdown document format.
+type markdownFormat struct{}
+
+func (markdownFormat) markers() [][]string {
+	return knownTocMarkers
+}
+
+func (markdownFormat) ignoreMarker() string {
+	return tocIgnoreMarker
+}
+
+func (markdownFormat) ignoreMarkerSkipsBlank() bool {
+	return false
+}
+
+func (markdownFormat) slugger() Slugger {
+	return nil
+}
+
+func (markdownFormat) hasTitleAnchor() bool {
+	return true
+}
+
+func (markdownFormat) parse(lines []string) *parsedDocument {
+	return parseMarkdown(lines)
+}
+
+func (markdownFormat) renderTitle(title string, level int) []string {
+	return []string{strings.Repeat("#", level) + " " + title, ""}
+}
+
+func (markdownFormat) renderEntries(entries []*tocEntry, minDepth int, sep string, ordered bool) []string {
+	var lines []string
+	var counters []int
+	for _, h := range entries {
+		offsetDepth := h.depth - minDepth
+		if !ordered {
+			lines = append(lines, fmt.Sprintf("%s%s [%s](%s)", strings.Repeat("  ", offsetDepth), sep, h.title, h.link))
+			continue
+		}
+		counters = getSectionCounters(counters, offsetDepth)
+		// The nested ordered lists are indented past the marker of the
+		// parent item, e.g. "10. ".
+		lines = append(lines, fmt.Sprintf("%s%d. [%s](%s)", strings.Repeat("    ", offsetDepth), counters[offsetDepth], h.title, h.link))
+	}
+	return append(lines, "")
+}
+
+func (markdownFormat) setHeadingNumber(lines []string, h *parsedHeading, number string) {
+	line := lines[h.text]
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	s := line[len(indent):]
+	if h.underline == 0 {
+		// ATX heading
+		level := len(s) - len(strings.TrimLeft(s, "#"))
+		indent += s[:level] + " "
+		s = strings.TrimLeft(s[level:], " \t")
+	} else if !strings.Contains(strings.TrimSuffix(number, "."), ".") {
+		// The text of Setext heading starting with "1. " is a list item.
+		number = strings.TrimSuffix(number, ".") + "\\."
+	}
+	lines[h.text] = indent + number + " " + stripSectionNumber(s)
+}
+
+// getSectionCounters returns the counters of the sections, one per level,
+// after the counter of the provided level is incremented.
+func getSectionCounters(counters []int, level int) []int {
+	for len(counters) <= level {
+		counters = append(counters, 0)
+	}
+	counters = counters[:level+1]
+	counters[level]++
+	return counters
+}
+
+// getSectionNumber returns the section number, e.g. "1.2.", of the
+// provided counters.
+func getSectionNumber(counters []int) string {
+	var sb strings.Builder
+	for _, i := range counters {
+		sb.WriteString(strconv.Itoa(i) + ".")
+	}
+	return sb.String()
+}
+
+// stripSectionNumber removes the section number, e.g. "1.2.", from the
+// beginning of a heading.
+func stripSectionNumber(s string) string {
+	return reSectionNumber.ReplaceAllString(s, "")
+}
@@ -0,0 +1,177 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUpdateTocFormats(t *testing.T) {
+	testcases := []struct {
+		name   string
+		input  []string
+		output []string
+	}{
+		{
+			name: "README.rst",
+			input: []string{
+				"=======",
+				"Project",
+				"=======",
+				"",
+				"Intro text::",
+				"",
+				"    Not a Title",
+				"    -----------",
+				"",
+				"Install",
+				"-------",
+				"",
+				"From Source",
+				"~~~~~~~~~~~",
+				"",
+				".. toc-ignore",
+				"",
+				"Internal",
+				"--------",
+				"",
+				"Usage",
+				"-----",
+			},
+			output: []string{
+				"=======",
+				"Project",
+				"=======",
+				"",
+				"Intro text::",
+				"",
+				"    Not a Title",
+				"    -----------",
+				"",
+				".. begin-toc",
+				"",
+				".. rubric:: Table of Contents",
+				"",
+				"* `Install`_",
+				"",
+				"  * `From Source`_",
+				"",
+				"* `Usage`_",
+				"",
+				".. end-toc",
+				"",
+				"Install",
+				"-------",
+				"",
+				"From Source",
+				"~~~~~~~~~~~",
+				"",
+				".. toc-ignore",
+				"",
+				"Internal",
+				"--------",
+				"",
+				"Usage",
+				"-----",
+			},
+		},
+		{
+			name: "README.adoc",
+			input: []string{
+				"= Project",
+				"",
+				"== Getting Started",
+				"",
+				"----",
+				"== Not a Title",
+				"----",
+				"",
+				"[[custom-id]]",
+				"=== Install & Setup",
+				"",
+				"[discrete]",
+				"== Discrete Heading",
+				"",
+				"== Getting Started",
+				"",
+				"== Version 1.0 - Notes",
+			},
+			output: []string{
+				"= Project",
+				"",
+				"// begin-toc",
+				".Table of Contents",
+				"* <<_getting_started,Getting Started>>",
+				"** <<custom-id,Install & Setup>>",
+				"* <<_getting_started_2,Getting Started>>",
+				"* <<_version_1_0_notes,Version 1.0 - Notes>>",
+				"",
+				"// end-toc",
+				"",
+				"== Getting Started",
+				"",
+				"----",
+				"== Not a Title",
+				"----",
+				"",
+				"[[custom-id]]",
+				"=== Install & Setup",
+				"",
+				"[discrete]",
+				"== Discrete Heading",
+				"",
+				"== Getting Started",
+				"",
+				"== Version 1.0 - Notes",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		fp := filepath.Join(t.TempDir(), tc.name)
+		writeTestFile(t, fp, strings.Join(tc.input, "\n")+"\n")
+		toc := NewTableOfContents()
+		toc.AddFilePath(fp)
+		if err := UpdateToc(toc); err != nil {
+			t.Fatalf("FAIL: %s: %v", tc.name, err)
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			t.Fatalf("FAIL: %s: %v", tc.name, err)
+		}
+		expected := strings.Join(tc.output, "\n") + "\n"
+		if string(b) != expected {
+			t.Fatalf("FAIL: %s: output mismatch:\n>>>got:\n%s\n>>>expected:\n%s", tc.name, b, expected)
+		}
+
+		// The update is idempotent.
+		toc = NewTableOfContents()
+		toc.AddFilePath(fp)
+		if err := UpdateToc(toc); err != nil {
+			t.Fatalf("FAIL: %s: %v", tc.name, err)
+		}
+		if b, _ := ioutil.ReadFile(fp); string(b) != expected {
+			t.Fatalf("FAIL: %s: second update changed the output:\n%s", tc.name, b)
+		}
+	}
+
+	toc := NewTableOfContents()
+	if err := toc.AddFormat("textile"); err == nil {
+		t.Fatalf("FAIL: expected error for unsupported format")
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/ast"
+	goformat "go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var generateTemplates embed.FS
+
+// generateFormats are the templates of the generated files by format.
+var generateFormats = map[string]string{
+	"go":     "templates/go.tmpl",
+	"golang": "templates/go.tmpl",
+}
+
+// generatedMarker is the first line of the generated files. The text
+// before it, e.g. a license header, is kept when the file is regenerated.
+const generatedMarker = "// Code generated by versioned. DO NOT EDIT."
+
+// GenerateOptions are the options of the generation of a file with the
+// package metadata.
+type GenerateOptions struct {
+	// Package is the name of the Go package. By default, it is the package
+	// of the existing file, or of the other Go files in the directory, or
+	// main.
+	Package string
+	// Variable is the name of the package manager variable. By default, it
+	// is the one of the existing file, or app.
+	Variable string
+	// KeepMetadata keeps the git and build metadata, i.e. the defaults of
+	// the Set* calls, of the existing file when the package metadata has
+	// none, e.g. when the file is regenerated after the version bump.
+	KeepMetadata bool
+}
+
+// generateTemplateData is the data of the templates of the generated
+// files.
+type generateTemplateData struct {
+	Package  string
+	Variable string
+	Vars     *LdflagsVariables
+	Pkg      *PackageManager
+}
+
+// GenerateFile writes a complete file of the format, i.e. go, with the
+// package metadata, e.g. version.go with the init function creating the
+// package manager, the Set* calls, and the variables set with -ldflags,
+// see NewLdflagsVariables. The existing file must be generated by
+// GenerateFile. Its package, package manager variable, name, description,
+// and documentation are kept, unless set in the options or the package
+// metadata, and so are the defaults of the Set* calls, e.g. the git commit,
+// with the KeepMetadata option. Therefore, the file is regenerated, e.g.
+// after the version bump, with the same result. It returns true when the
+// file changed.
+func GenerateFile(fp string, pkg *PackageManager, format string, opts *GenerateOptions) (bool, error) {
+	tmplPath, exists := generateFormats[format]
+	if !exists {
+		return false, fmt.Errorf("generate format %q is unsupported", format)
+	}
+	if opts == nil {
+		opts = &GenerateOptions{}
+	}
+	data := &generateTemplateData{
+		Package:  opts.Package,
+		Variable: opts.Variable,
+		Vars:     NewLdflagsVariables(),
+	}
+	p := *pkg
+	data.Pkg = &p
+
+	var header, src []byte
+	src, err := ioutil.ReadFile(fp)
+	switch {
+	case err == nil:
+		i := bytes.Index(src, []byte(generatedMarker))
+		if i < 0 {
+			return false, fmt.Errorf("file %s is not generated by versioned", fp)
+		}
+		header = src[:i]
+		if err := data.inspect(fp, src, opts.KeepMetadata); err != nil {
+			return false, err
+		}
+	case os.IsNotExist(err):
+		// The file is created.
+	default:
+		return false, err
+	}
+	if data.Package == "" {
+		data.Package = getGoPackageName(filepath.Dir(fp))
+	}
+	if data.Variable == "" {
+		data.Variable = "app"
+	}
+	if data.Pkg.Name == "" {
+		data.Pkg.Name = filepath.Base(filepath.Dir(fp))
+	}
+
+	tmpl, err := generateTemplates.ReadFile(tmplPath)
+	if err != nil {
+		return false, err
+	}
+	t, err := template.New("").Parse(string(tmpl))
+	if err != nil {
+		return false, fmt.Errorf("failed parsing template: %v", err)
+	}
+	var buffer bytes.Buffer
+	buffer.Write(header)
+	if err := t.Execute(&buffer, data); err != nil {
+		return false, fmt.Errorf("failed executing template: %v", err)
+	}
+	b, err := goformat.Source(buffer.Bytes())
+	if err != nil {
+		return false, fmt.Errorf("failed formatting %s: %v", fp, err)
+	}
+	if bytes.Equal(b, src) {
+		return false, nil
+	}
+	if src == nil {
+		return true, ioutil.WriteFile(fp, b, 0644)
+	}
+	return true, writeSyncedFile(fp, b)
+}
+
+// inspect sets the empty package, package manager variable, name,
+// description, and documentation to the ones of the existing file. The
+// empty git and build metadata are set to the ones of the file when
+// keepMetadata is true.
+func (data *generateTemplateData) inspect(fp string, src []byte, keepMetadata bool) error {
+	f, err := parser.ParseFile(token.NewFileSet(), fp, src, 0)
+	if err != nil {
+		return err
+	}
+	if data.Package == "" {
+		data.Package = f.Name.Name
+	}
+	stringValue := func(expr ast.Expr) string {
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return ""
+		}
+		s, _ := strconv.Unquote(lit.Value)
+		return s
+	}
+	// The metadata set with the defaults of the Set* calls.
+	defaults := map[string]*string{
+		"SetGitBranch":      &data.Pkg.Git.Branch,
+		"SetGitCommit":      &data.Pkg.Git.Commit,
+		"SetGitTag":         &data.Pkg.Git.Tag,
+		"SetGitCommitDate":  &data.Pkg.Git.CommitDate,
+		"SetBuildUser":      &data.Pkg.Build.User,
+		"SetBuildDate":      &data.Pkg.Build.Date,
+		"SetBuildHost":      &data.Pkg.Build.Host,
+		"SetBuildGoVersion": &data.Pkg.Build.GoVersion,
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || !keepMetadata || len(call.Args) != 2 {
+				return true
+			}
+			if v, exists := defaults[sel.Sel.Name]; exists && *v == "" {
+				*v = stringValue(call.Args[1])
+			}
+			return true
+		}
+		s, ok := n.(*ast.AssignStmt)
+		if !ok || len(s.Lhs) != 1 || len(s.Rhs) != 1 {
+			return true
+		}
+		switch lhs := s.Lhs[0].(type) {
+		case *ast.Ident:
+			call, ok := s.Rhs[0].(*ast.CallExpr)
+			if !ok || !isGoPackageCall(call, "versioned", "NewPackageManager") || len(call.Args) != 1 {
+				return true
+			}
+			if data.Variable == "" {
+				data.Variable = lhs.Name
+			}
+			if data.Pkg.Name == "" {
+				data.Pkg.Name = stringValue(call.Args[0])
+			}
+		case *ast.SelectorExpr:
+			switch lhs.Sel.Name {
+			case "Description":
+				if data.Pkg.Description == "" {
+					data.Pkg.Description = stringValue(s.Rhs[0])
+				}
+			case "Documentation":
+				if data.Pkg.Documentation == "" {
+					data.Pkg.Documentation = stringValue(s.Rhs[0])
+				}
+			}
+		}
+		return true
+	})
+	return nil
+}
+
+// getGoPackageName returns the name of the package of the Go files in the
+// directory, or main.
+func getGoPackageName(dir string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, m := range matches {
+		if strings.HasSuffix(m, "_test.go") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), m, nil, parser.PackageClauseOnly)
+		if err == nil {
+			return f.Name.Name
+		}
+	}
+	return "main"
+}
@@ -0,0 +1,128 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "myapp")
+	fp := filepath.Join(dir, "version.go")
+	writeTestFile(t, filepath.Join(dir, "app.go"), "package myapp\n")
+
+	pkg := NewPackageManager("")
+	pkg.Version = "1.0.0"
+	pkg.Description = "My app"
+	pkg.Git.Commit = "v1.0.0"
+	pkg.Build.User = "ci"
+
+	for i, test := range []struct {
+		pkg      *PackageManager
+		opts     *GenerateOptions
+		header   string
+		changed  bool
+		contains []string
+	}{
+		{
+			// The file is created.
+			pkg:     pkg,
+			opts:    &GenerateOptions{Variable: "pm"},
+			changed: true,
+			contains: []string{
+				"// Code generated by versioned. DO NOT EDIT.\n\npackage myapp\n",
+				"\tpm             *versioned.PackageManager\n\tappVersion     string\n",
+				"\tpm = versioned.NewPackageManager(\"myapp\")\n\tpm.Description = \"My app\"\n",
+				"\tpm.SetVersion(appVersion, \"1.0.0\")\n",
+				"\tpm.SetGitCommit(gitCommit, \"v1.0.0\")\n",
+				"\tpm.FromBuildInfo()\n",
+			},
+		},
+		{
+			// The file is regenerated with the same result.
+			pkg: pkg,
+		},
+		{
+			// The license header is kept, and the package, the variable,
+			// the name, the description, and the defaults of the existing
+			// file are kept.
+			pkg:     &PackageManager{Version: "1.1.0"},
+			opts:    &GenerateOptions{KeepMetadata: true},
+			header:  "// Copyright 2020 Paul Greenberg\n\n",
+			changed: true,
+			contains: []string{
+				"// Copyright 2020 Paul Greenberg\n\n// Code generated by versioned. DO NOT EDIT.\n\npackage myapp\n",
+				"\tpm = versioned.NewPackageManager(\"myapp\")\n\tpm.Description = \"My app\"\n",
+				"\tpm.SetVersion(appVersion, \"1.1.0\")\n",
+				"\tpm.SetGitCommit(gitCommit, \"v1.0.0\")\n",
+				"\tpm.SetBuildUser(buildUser, \"ci\")\n",
+			},
+		},
+		{
+			// The defaults are cleared without the git and build metadata,
+			// e.g. when synced with -release.
+			pkg:     &PackageManager{Version: "1.1.0"},
+			changed: true,
+			contains: []string{
+				"\tpm = versioned.NewPackageManager(\"myapp\")\n\tpm.Description = \"My app\"\n",
+				"\tpm.SetGitCommit(gitCommit, \"\")\n",
+				"\tpm.SetBuildUser(buildUser, \"\")\n",
+			},
+		},
+	} {
+		if test.header != "" {
+			b, err := ioutil.ReadFile(fp)
+			if err != nil {
+				t.Fatalf("FAIL: test %d: %v", i, err)
+			}
+			writeTestFile(t, fp, test.header+string(b))
+		}
+		changed, err := GenerateFile(fp, test.pkg, "go", test.opts)
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if changed != test.changed {
+			t.Fatalf("FAIL: test %d: changed: %t (actual) vs. %t (expected)", i, changed, test.changed)
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		for _, s := range test.contains {
+			if !strings.Contains(string(b), s) {
+				t.Fatalf("FAIL: test %d: %q not found in:\n%s", i, s, b)
+			}
+		}
+	}
+
+	// The generated file is synchronized.
+	pkg.Version = "1.2.0"
+	changed, err := SyncFile(fp, pkg, "", nil)
+	if err != nil || !changed {
+		t.Fatalf("FAIL: sync: changed: %t, error: %v", changed, err)
+	}
+	if _, err := GenerateFile(fp, pkg, "python", nil); err == nil {
+		t.Fatalf("FAIL: expected error, but succeeded")
+	}
+
+	// The file not generated by versioned is not overwritten.
+	writeTestFile(t, fp, "package myapp\n")
+	if _, err := GenerateFile(fp, pkg, "go", nil); err == nil {
+		t.Fatalf("FAIL: expected error, but succeeded")
+	}
+}
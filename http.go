@@ -0,0 +1,103 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Handler returns the HTTP handler serving the package metadata as JSON,
+// e.g. mux.Handle("/version", app.Handler()).
+func (p *PackageManager) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		b, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(append(b, '\n'))
+	})
+}
+
+// MetricsHandler returns the HTTP handler serving the package metadata in
+// Prometheus text exposition format, i.e. the build_info gauge with the
+// value 1 and the metadata in the labels, e.g. mux.Handle("/metrics",
+// app.MetricsHandler()).
+func (p *PackageManager) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		p.WriteMetrics(w)
+	})
+}
+
+// WriteMetrics writes the build_info gauge of the package metadata in
+// Prometheus text exposition format.
+func (p *PackageManager) WriteMetrics(w io.Writer) error {
+	labels := [][2]string{
+		{"name", p.Name},
+		{"version", p.Version},
+		{"branch", p.Git.Branch},
+		{"commit", p.Git.Commit},
+		{"tag", p.Git.Tag},
+		{"dirty", strconv.FormatBool(p.Git.Dirty)},
+		{"goversion", p.Build.GoVersion},
+		{"goos", p.Build.OperatingSystem},
+		{"goarch", p.Build.Architecture},
+	}
+	var sb strings.Builder
+	sb.WriteString("# HELP build_info A metric with a constant '1' value labeled by the build information.\n")
+	sb.WriteString("# TYPE build_info gauge\n")
+	sb.WriteString("build_info{")
+	for i, kv := range labels {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(fmt.Sprintf("%s=\"%s\"", kv[0], escapeMetricLabel(kv[1])))
+	}
+	sb.WriteString("} 1\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// escapeMetricLabel escapes the backslashes, the double quotes, and the
+// line feeds in the label value.
+func escapeMetricLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// PublishExpvar publishes the package metadata as the expvar variable,
+// e.g. "version", served at /debug/vars. Unlike expvar.Publish, it
+// returns an error when the variable exists.
+func (p *PackageManager) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar variable %s is already published", name)
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		return p
+	}))
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"encoding/json"
+	"expvar"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func newTestPackageManager() *PackageManager {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.0.0"
+	pkg.Git.Branch = "main"
+	pkg.Git.Commit = "v1.0.0-1-g0c85fbc"
+	pkg.Git.Dirty = true
+	pkg.Build.OperatingSystem = "linux"
+	pkg.Build.Architecture = "amd64"
+	pkg.Build.GoVersion = "go1.22.1"
+	pkg.Description = "My \"app\"\nand more"
+	return pkg
+}
+
+func TestHandler(t *testing.T) {
+	pkg := newTestPackageManager()
+	mux := http.NewServeMux()
+	mux.Handle("/version", pkg.Handler())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/version")
+	if err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("FAIL: status code: %d (actual) vs. %d (expected)", resp.StatusCode, http.StatusOK)
+	}
+	if s := resp.Header.Get("Content-Type"); s != "application/json" {
+		t.Fatalf("FAIL: content type: %s (actual) vs. application/json (expected)", s)
+	}
+	got := &PackageManager{}
+	if err := json.NewDecoder(resp.Body).Decode(got); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if !reflect.DeepEqual(got, pkg) {
+		t.Fatalf("FAIL: package mismatch:\n%+v (actual)\n%+v (expected)", got, pkg)
+	}
+
+	resp, err = http.Post(srv.URL+"/version", "application/json", nil)
+	if err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatalf("FAIL: status code: %d (actual) vs. %d (expected)", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	pkg := newTestPackageManager()
+	pkg.Name = "my\\app"
+	w := httptest.NewRecorder()
+	pkg.MetricsHandler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	expected := "# HELP build_info A metric with a constant '1' value labeled by the build information.\n" +
+		"# TYPE build_info gauge\n" +
+		"build_info{name=\"my\\\\app\",version=\"1.0.0\",branch=\"main\",commit=\"v1.0.0-1-g0c85fbc\",tag=\"\"," +
+		"dirty=\"true\",goversion=\"go1.22.1\",goos=\"linux\",goarch=\"amd64\"} 1\n"
+	if s := w.Body.String(); s != expected {
+		t.Fatalf("FAIL: metrics mismatch:\n%s (actual)\n%s (expected)", s, expected)
+	}
+	if s := w.Header().Get("Content-Type"); !strings.HasPrefix(s, "text/plain; version=0.0.4") {
+		t.Fatalf("FAIL: content type: %s", s)
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	pkg := newTestPackageManager()
+	if err := pkg.PublishExpvar("versioned_test"); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if err := pkg.PublishExpvar("versioned_test"); err == nil {
+		t.Fatalf("FAIL: expected error, but succeeded")
+	}
+
+	w := httptest.NewRecorder()
+	expvar.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/debug/vars", nil))
+	b, err := ioutil.ReadAll(w.Body)
+	if err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	vars := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(b, &vars); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	got := &PackageManager{}
+	if err := json.Unmarshal(vars["versioned_test"], got); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if !reflect.DeepEqual(got, pkg) {
+		t.Fatalf("FAIL: package mismatch:\n%+v (actual)\n%+v (expected)", got, pkg)
+	}
+}
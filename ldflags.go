@@ -0,0 +1,99 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LdflagsVariables are the names of the string variables set with the -X
+// flags of the linker, e.g. appVersion. The variables with empty names
+// are skipped.
+type LdflagsVariables struct {
+	Version        string
+	GitBranch      string
+	GitCommit      string
+	GitTag         string
+	GitCommitDate  string
+	GitDirty       string
+	BuildUser      string
+	BuildDate      string
+	BuildHost      string
+	BuildGoVersion string
+	BuildCGO       string
+}
+
+// NewLdflagsVariables returns an instance of LdflagsVariables with the
+// default names, i.e. appVersion, gitBranch, gitCommit, gitTag,
+// gitCommitDate, gitDirty, buildUser, buildDate, buildHost,
+// buildGoVersion, and buildCgo.
+func NewLdflagsVariables() *LdflagsVariables {
+	return &LdflagsVariables{
+		Version:        "appVersion",
+		GitBranch:      "gitBranch",
+		GitCommit:      "gitCommit",
+		GitTag:         "gitTag",
+		GitCommitDate:  "gitCommitDate",
+		GitDirty:       "gitDirty",
+		BuildUser:      "buildUser",
+		BuildDate:      "buildDate",
+		BuildHost:      "buildHost",
+		BuildGoVersion: "buildGoVersion",
+		BuildCGO:       "buildCgo",
+	}
+}
+
+// Ldflags returns the -X flags of the linker setting the variables of the
+// package, e.g. main, to the attributes of PackageManager. When the
+// variables are nil, the ones returned by NewLdflagsVariables are used.
+// The attributes with empty values are skipped.
+func (p *PackageManager) Ldflags(pkgPath string, vars *LdflagsVariables) string {
+	if vars == nil {
+		vars = NewLdflagsVariables()
+	}
+	var flags []string
+	for _, kv := range [][2]string{
+		{vars.Version, p.Version},
+		{vars.GitBranch, p.Git.Branch},
+		{vars.GitCommit, p.Git.Commit},
+		{vars.GitTag, p.Git.Tag},
+		{vars.GitCommitDate, p.Git.CommitDate},
+		{vars.GitDirty, strconv.FormatBool(p.Git.Dirty)},
+		{vars.BuildUser, p.Build.User},
+		{vars.BuildDate, p.Build.Date},
+		{vars.BuildHost, p.Build.Host},
+		{vars.BuildGoVersion, p.Build.GoVersion},
+		{vars.BuildCGO, strconv.FormatBool(p.Build.CGO)},
+	} {
+		if kv[0] == "" || kv[1] == "" {
+			continue
+		}
+		flags = append(flags, "-X", quoteLdflag(pkgPath+"."+kv[0]+"="+kv[1]))
+	}
+	return strings.Join(flags, " ")
+}
+
+// quoteLdflag quotes the argument of the flag with white space or quotes,
+// the same way the go command splits -ldflags.
+func quoteLdflag(s string) string {
+	if !strings.ContainsAny(s, " \t\n\r'\"") {
+		return s
+	}
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	return "\"" + s + "\""
+}
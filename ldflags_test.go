@@ -0,0 +1,49 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"testing"
+)
+
+func TestLdflags(t *testing.T) {
+	p := NewPackageManager("myapp")
+	p.Version = "1.0.0"
+	p.Git.Commit = "v1.0.0-dirty"
+	p.Git.Dirty = true
+	p.Build.User = "John Doe"
+	p.Build.Host = "it's"
+
+	for i, test := range []struct {
+		pkgPath string
+		vars    *LdflagsVariables
+		output  string
+	}{
+		{
+			pkgPath: "main",
+			output: "-X main.appVersion=1.0.0 -X main.gitCommit=v1.0.0-dirty -X main.gitDirty=true " +
+				"-X 'main.buildUser=John Doe' -X \"main.buildHost=it's\" -X main.buildCgo=false",
+		},
+		{
+			pkgPath: "github.com/me/myapp/internal/version",
+			vars:    &LdflagsVariables{Version: "Version", GitCommit: "Commit"},
+			output:  "-X github.com/me/myapp/internal/version.Version=1.0.0 -X github.com/me/myapp/internal/version.Commit=v1.0.0-dirty",
+		},
+	} {
+		if s := p.Ldflags(test.pkgPath, test.vars); s != test.output {
+			t.Fatalf("FAIL: test %d: ldflags mismatch:\n%s (actual)\n%s (expected)", i, s, test.output)
+		}
+	}
+}
@@ -24,6 +24,7 @@ import (
 	// "log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 	"unicode"
@@ -47,18 +48,20 @@ var (
 
 // LicenseHeader represent license headers.
 type LicenseHeader struct {
-	FilePath        string
-	FileExtension   string
-	Year            uint64
-	CopyrightHolder string
-	LicenseType     string
-	Action          string
-	wrapChars       []string
-	raw             []byte
-	offset          int
-	found           bool
-	match           bool
-	mismatchText    string
+	FilePath         string
+	FileExtension    string
+	Year             uint64
+	CopyrightHolder  string
+	CopyrightHolders []string
+	AuthorsFile      string
+	LicenseType      string
+	Action           string
+	wrapChars        []string
+	raw              []byte
+	offset           int
+	found            bool
+	match            bool
+	mismatchText     string
 }
 
 // NewLicenseHeader returns an instance of LicenseHeader.
@@ -134,22 +137,22 @@ func (h *LicenseHeader) inspect() error {
 
 	if bytes.Contains(header, []byte(licenseClues[h.LicenseType])) {
 		h.found = true
-		if bytes.Contains(bytes.TrimSpace(header), bytes.TrimSpace(h.raw)) {
-			h.match = true
-		}
-		// Approximate match.
-		if !h.match {
-			reY := regexp.MustCompile(`\s(\d{4})\s`)
-			reW := regexp.MustCompile(`\s*`)
-			// Remove copyright year.
-			actual := reY.ReplaceAll(header, []byte(" "))
-			expected := reY.ReplaceAll(h.raw, []byte(" "))
-			// Remove all whitespaces.
-			actual = reW.ReplaceAll(actual, []byte(""))
-			expected = reW.ReplaceAll(expected, []byte(""))
-			if bytes.Contains(actual, expected) {
+		// The copyright holders are compared as a set, i.e. in any order.
+		actualHolders, actual := splitCopyright(header)
+		expectedHolders, expected := splitCopyright(h.raw)
+		if equalHolders(actualHolders, expectedHolders) {
+			if bytes.Contains(bytes.TrimSpace(header), bytes.TrimSpace(h.raw)) {
 				h.match = true
 			}
+			// Approximate match. The copyright years and whitespaces are ignored.
+			if !h.match {
+				reW := regexp.MustCompile(`\s*`)
+				actual = reW.ReplaceAll(actual, []byte(""))
+				expected = reW.ReplaceAll(expected, []byte(""))
+				if bytes.Contains(actual, expected) {
+					h.match = true
+				}
+			}
 		}
 
 		if !h.match {
@@ -279,15 +282,93 @@ func (h *LicenseHeader) AddFilePath(fp string) error {
 	return nil
 }
 
-// AddCopyrightHolder adds copyright holder.
+// AddCopyrightHolder adds copyright holder. When called more than once,
+// the header lists every holder on its own copyright line.
 func (h *LicenseHeader) AddCopyrightHolder(s string) error {
 	if s == "" {
 		return fmt.Errorf("copyright holder is empty")
 	}
-	h.CopyrightHolder = s
+	if h.CopyrightHolder == "" {
+		h.CopyrightHolder = s
+	}
+	for _, holder := range h.CopyrightHolders {
+		if holder == s {
+			return nil
+		}
+	}
+	h.CopyrightHolders = append(h.CopyrightHolders, s)
 	return nil
 }
 
+// AddAuthors adds "The PROJECT Authors" copyright holder. The copyright
+// line of the holder refers to the AUTHORS file, e.g.
+// "Copyright 2020 The Foo Authors. See AUTHORS file."
+func (h *LicenseHeader) AddAuthors(project string) error {
+	if project == "" {
+		return fmt.Errorf("project name is empty")
+	}
+	h.AuthorsFile = "AUTHORS"
+	return h.AddCopyrightHolder(fmt.Sprintf("The %s Authors", project))
+}
+
+// CopyrightLines returns copyright lines, one per copyright holder.
+// The format takes the year and the holder, e.g. "Copyright %d %s".
+func (h *LicenseHeader) CopyrightLines(format string) []string {
+	holders := h.CopyrightHolders
+	if len(holders) == 0 {
+		holders = []string{h.CopyrightHolder}
+	}
+	var lines []string
+	for _, holder := range holders {
+		line := fmt.Sprintf(format, h.Year, holder)
+		if h.AuthorsFile != "" && strings.HasPrefix(holder, "The ") && strings.HasSuffix(holder, " Authors") {
+			line = strings.TrimSuffix(line, ".") + ". See " + h.AuthorsFile + " file."
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitCopyright separates copyright lines from the rest of a header.
+// It returns the sorted list of copyright holders found in the lines
+// and the header without the lines.
+func splitCopyright(b []byte) ([]string, []byte) {
+	reYear := regexp.MustCompile(`^(\(c\)|\(C\)|©)?\s*\d{4}(\s*[-,]\s*\d{4})*\s+`)
+	reAuthors := regexp.MustCompile(`\s*See \S+ file\.?$`)
+	reReserved := regexp.MustCompile(`(?i)\.?\s*All Rights Reserved\.?$`)
+	var holders []string
+	var body bytes.Buffer
+	r := bufio.NewScanner(bytes.NewReader(b))
+	for r.Scan() {
+		line := r.Text()
+		s := strings.TrimLeft(line, " \t/*#")
+		if !strings.HasPrefix(s, "Copyright ") {
+			body.WriteString(line + "\n")
+			continue
+		}
+		s = strings.TrimSpace(strings.TrimPrefix(s, "Copyright "))
+		s = reYear.ReplaceAllString(s, "")
+		s = reAuthors.ReplaceAllString(s, "")
+		s = reReserved.ReplaceAllString(s, "")
+		s = strings.TrimSuffix(strings.TrimSpace(s), ".")
+		holders = append(holders, s)
+	}
+	sort.Strings(holders)
+	return holders, body.Bytes()
+}
+
+func equalHolders(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // AddYear adds copyright year.
 func (h *LicenseHeader) AddYear(i uint64) error {
 	if i == 0 {
@@ -368,8 +449,8 @@ func (h *LicenseHeader) build() error {
 	return nil
 }
 
-const tmplApache = `Copyright {{.Year}} {{.CopyrightHolder}}
-
+const tmplApache = `{{range .CopyrightLines "Copyright %d %s"}}{{.}}
+{{end}}
 Licensed under the Apache License, Version 2.0 (the "License");
 you may not use this file except in compliance with the License.
 You may obtain a copy of the License at
@@ -382,8 +463,8 @@ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
 See the License for the specific language governing permissions and
 limitations under the License.`
 
-const tmplAsl = `Copyright {{.Year}} {{.CopyrightHolder}}. All Rights Reserved.
-
+const tmplAsl = `{{range .CopyrightLines "Copyright %d %s. All Rights Reserved."}}{{.}}
+{{end}}
 Licensed under the Amazon Software License (the "License").
 You may not use this file except in compliance with the License.
 A copy of the License is located at
@@ -395,8 +476,8 @@ on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
 express or implied. See the License for the specific language governing
 permissions and limitations under the License.`
 
-const tmplMit = `Copyright (c) {{.Year}} {{.CopyrightHolder}}
-
+const tmplMit = `{{range .CopyrightLines "Copyright (c) %d %s"}}{{.}}
+{{end}}
 Licensed under the MIT License.
 
 Permission is hereby granted, free of charge, to any person obtaining a copy
@@ -417,8 +498,8 @@ LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
 OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
 SOFTWARE.`
 
-const tmplGpl3 = `Copyright (C) {{.Year}} {{.CopyrightHolder}}
-
+const tmplGpl3 = `{{range .CopyrightLines "Copyright (C) %d %s"}}{{.}}
+{{end}}
 Licensed under the GPLv3 License.
 
 This program is free software: you can redistribute it and/or modify
@@ -0,0 +1,250 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed licenses/*.txt
+var licenseTexts embed.FS
+
+var (
+	licenseNames = map[string]string{
+		"apache": "Apache License, Version 2.0",
+		"asl":    "Amazon Software License",
+		"mit":    "MIT License",
+		"gpl3":   "GNU General Public License, Version 3",
+	}
+
+	// licenseTextClues are the fragments of full license texts, e.g.
+	// LICENSE file. All fragments must be present for a match. The
+	// whitespaces in both the fragments and the text are collapsed.
+	// The more specific entries come first.
+	licenseTextClues = []struct {
+		licenseType string
+		clues       []string
+	}{
+		{"apache", []string{"Apache License", "Version 2.0, January 2004"}},
+		{"asl", []string{"Amazon Software License"}},
+		{"agpl3", []string{"GNU AFFERO GENERAL PUBLIC LICENSE", "Version 3"}},
+		{"lgpl3", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 3"}},
+		{"lgpl21", []string{"GNU LESSER GENERAL PUBLIC LICENSE", "Version 2.1"}},
+		{"gpl3", []string{"GNU GENERAL PUBLIC LICENSE", "Version 3, 29 June 2007"}},
+		{"gpl2", []string{"GNU GENERAL PUBLIC LICENSE", "Version 2, June 1991"}},
+		{"mpl2", []string{"Mozilla Public License", "2.0"}},
+		{"mit", []string{"Permission is hereby granted, free of charge, to any person obtaining a copy"}},
+		{"bsd3", []string{"Redistribution and use in source and binary forms", "Neither the name"}},
+		{"bsd2", []string{"Redistribution and use in source and binary forms"}},
+		{"isc", []string{"distribute this software for any purpose with or without fee is hereby granted"}},
+		{"unlicense", []string{"This is free and unencumbered software released into the public domain"}},
+	}
+)
+
+// WriteLicenseFile writes the full text of the license to a file,
+// e.g. LICENSE. The license type, copyright year and holders are
+// taken from the provided license header.
+func WriteLicenseFile(h *LicenseHeader, fp string) error {
+	b, err := h.buildLicenseText()
+	if err != nil {
+		return err
+	}
+	return writeLicenseFile(fp, b)
+}
+
+// WriteNoticeFile writes a file, e.g. NOTICE, listing the copyright
+// holders of the provided license header.
+func WriteNoticeFile(h *LicenseHeader, fp string) error {
+	if h.CopyrightHolder == "" && len(h.CopyrightHolders) == 0 {
+		return fmt.Errorf("copyright holder is empty")
+	}
+	if h.Year == 0 {
+		return fmt.Errorf("copyright year is empty")
+	}
+	var b bytes.Buffer
+	for _, line := range h.CopyrightLines("Copyright %d %s") {
+		fmt.Fprintln(&b, line)
+	}
+	fmt.Fprintln(&b)
+	fmt.Fprintf(&b, "Licensed under the %s. See LICENSE file.\n", licenseNames[h.LicenseType])
+	return writeLicenseFile(fp, b.Bytes())
+}
+
+// CheckLicenseFile checks that the license text in a file, e.g. LICENSE,
+// is of the same license type as the one of the provided license header.
+func CheckLicenseFile(h *LicenseHeader, fp string) error {
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return fmt.Errorf("failed reading file %q: %v", fp, err)
+	}
+	licenseType := detectLicenseType(b)
+	if licenseType == "" {
+		return fmt.Errorf("failed detecting license type in %q", fp)
+	}
+	if licenseType != h.LicenseType {
+		return fmt.Errorf("found license type mismatch in %q: %s (found) vs. %s (expected)", fp, licenseType, h.LicenseType)
+	}
+	return nil
+}
+
+// CheckLicenseFileHeaders checks that the license text in a file, e.g.
+// LICENSE, is of the same license type as the license headers of the
+// source files in the paths, see DetectHeaderLicenseType. It returns the
+// license type of the headers.
+func CheckLicenseFileHeaders(fp string, paths ...string) (string, error) {
+	licenseType, err := DetectHeaderLicenseType(paths...)
+	if err != nil {
+		return "", err
+	}
+	if licenseType == "" {
+		return "", fmt.Errorf("failed detecting license type in source headers of %s", strings.Join(paths, ", "))
+	}
+	h := NewLicenseHeader()
+	h.LicenseType = licenseType
+	return licenseType, CheckLicenseFile(h, fp)
+}
+
+// DetectHeaderLicenseType returns the license type of the license headers
+// of the source files, i.e. the files with the extensions supported by
+// AddLicense, in the paths, i.e. files or directories. The vendor,
+// node_modules, testdata, and hidden directories are skipped. It returns
+// an empty string when no license header is found, and an error when the
+// headers are of different license types.
+func DetectHeaderLicenseType(paths ...string) (string, error) {
+	var licenseTypes []string
+	for k := range licenseClues {
+		licenseTypes = append(licenseTypes, k)
+	}
+	sort.Strings(licenseTypes)
+
+	found := make(map[string]string)
+	for _, p := range paths {
+		err := filepath.Walk(p, func(fp string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() {
+				name := fi.Name()
+				if fp != p && (name == "vendor" || name == "node_modules" || name == "testdata" || strings.HasPrefix(name, ".")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			h := &LicenseHeader{FilePath: fp}
+			if h.getWrapChars() != nil {
+				return nil
+			}
+			b, err := ioutil.ReadFile(fp)
+			if err != nil {
+				return err
+			}
+			// The license header is at the top of the file.
+			if len(b) > 2048 {
+				b = b[:2048]
+			}
+			for _, licenseType := range licenseTypes {
+				if !bytes.Contains(b, []byte(licenseClues[licenseType])) {
+					continue
+				}
+				if _, exists := found[licenseType]; !exists {
+					found[licenseType] = fp
+				}
+				break
+			}
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+	if len(found) > 1 {
+		var entries []string
+		for licenseType, fp := range found {
+			entries = append(entries, fmt.Sprintf("%s in %q", licenseType, fp))
+		}
+		sort.Strings(entries)
+		return "", fmt.Errorf("found multiple license types in source headers: %s", strings.Join(entries, ", "))
+	}
+	for licenseType := range found {
+		return licenseType, nil
+	}
+	return "", nil
+}
+
+// detectLicenseType returns the license type of a full license text.
+// It returns an empty string when the type cannot be determined.
+func detectLicenseType(b []byte) string {
+	s := strings.Join(strings.Fields(string(b)), " ")
+	for _, entry := range licenseTextClues {
+		matched := true
+		for _, clue := range entry.clues {
+			if !strings.Contains(s, clue) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return entry.licenseType
+		}
+	}
+	return ""
+}
+
+func (h *LicenseHeader) buildLicenseText() ([]byte, error) {
+	tmpl, err := licenseTexts.ReadFile("licenses/" + h.LicenseType + ".txt")
+	if err != nil {
+		return nil, fmt.Errorf("full license text for license type %q is unsupported", h.LicenseType)
+	}
+	if h.LicenseType == "mit" {
+		if h.CopyrightHolder == "" && len(h.CopyrightHolders) == 0 {
+			return nil, fmt.Errorf("copyright holder is empty")
+		}
+		if h.Year == 0 {
+			return nil, fmt.Errorf("copyright year is empty")
+		}
+	}
+	t, err := template.New("").Parse(string(tmpl))
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing template: %v", err)
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, h); err != nil {
+		return nil, fmt.Errorf("failed executing template: %v", err)
+	}
+	return b.Bytes(), nil
+}
+
+func writeLicenseFile(fp string, b []byte) error {
+	var mode os.FileMode = 0644
+	fi, err := os.Stat(fp)
+	if err == nil {
+		if !fi.Mode().IsRegular() {
+			return fmt.Errorf("path %q is not a file", fp)
+		}
+		mode = fi.Mode().Perm()
+	}
+	if err := ioutil.WriteFile(fp, b, mode); err != nil {
+		return fmt.Errorf("failed writing file %q: %v", fp, err)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLicenseHeader(t *testing.T, fp string, holders ...string) *LicenseHeader {
+	h := NewLicenseHeader()
+	if fp != "" {
+		if err := h.AddFilePath(fp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.AddYear(2020); err != nil {
+		t.Fatal(err)
+	}
+	for _, holder := range holders {
+		if err := h.AddCopyrightHolder(holder); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return h
+}
+
+func TestLicenseHeaderCopyrightHolders(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "main.go")
+	if err := ioutil.WriteFile(fp, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	h := newTestLicenseHeader(t, fp)
+	if err := h.AddAuthors("Foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AddCopyrightHolder("Acme, Inc."); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddLicense(h); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"// Copyright 2020 The Foo Authors. See AUTHORS file.\n",
+		"// Copyright 2020 Acme, Inc.\n//\n// Licensed under the Apache License",
+	} {
+		if !strings.Contains(string(b), s) {
+			t.Fatalf("FAIL: expected header to contain %q, got:\n%s", s, b)
+		}
+	}
+
+	for i, test := range []struct {
+		holders   []string
+		authors   string
+		shouldErr bool
+	}{
+		{holders: []string{"Acme, Inc."}, authors: "Foo"},
+		{holders: []string{"Acme, Inc.", "The Foo Authors"}},
+		{holders: []string{"Acme, Inc."}, shouldErr: true},
+		{holders: []string{"Acme, Inc.", "Bar Corp."}, shouldErr: true},
+	} {
+		h := newTestLicenseHeader(t, fp, test.holders...)
+		if test.authors != "" {
+			if err := h.AddAuthors(test.authors); err != nil {
+				t.Fatal(err)
+			}
+		}
+		err := AddLicense(h)
+		if test.shouldErr && err == nil {
+			t.Fatalf("FAIL: Test %d: expected mismatch error, got success", i)
+		}
+		if !test.shouldErr && err != nil {
+			t.Fatalf("FAIL: Test %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestLicenseFile(t *testing.T) {
+	dir := t.TempDir()
+	fp := filepath.Join(dir, "LICENSE")
+
+	h := newTestLicenseHeader(t, "", "Paul Greenberg")
+	if err := h.AddLicenseType("mit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteLicenseFile(h, fp); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ioutil.ReadFile("LICENSE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("FAIL: LICENSE mismatch:\n>>>got:\n%s\n>>>expected:\n%s", got, want)
+	}
+	if err := CheckLicenseFile(h, fp); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, licenseType := range []string{"apache", "gpl3"} {
+		h := newTestLicenseHeader(t, "", "Paul Greenberg")
+		if err := h.AddLicenseType(licenseType); err != nil {
+			t.Fatal(err)
+		}
+		if err := CheckLicenseFile(h, fp); err == nil {
+			t.Fatalf("FAIL: expected %s vs. mit license type mismatch, got success", licenseType)
+		}
+		if err := WriteLicenseFile(h, fp); err != nil {
+			t.Fatal(err)
+		}
+		if err := CheckLicenseFile(h, fp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h = newTestLicenseHeader(t, "", "The Foo Authors", "Acme, Inc.")
+	fp = filepath.Join(dir, "NOTICE")
+	if err := WriteNoticeFile(h, fp); err != nil {
+		t.Fatal(err)
+	}
+	got, err = ioutil.ReadFile(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expNotice := "Copyright 2020 The Foo Authors\nCopyright 2020 Acme, Inc.\n\n" +
+		"Licensed under the Apache License, Version 2.0. See LICENSE file.\n"
+	if string(got) != expNotice {
+		t.Fatalf("FAIL: NOTICE mismatch:\n>>>got:\n%s\n>>>expected:\n%s", got, expNotice)
+	}
+}
+
+func TestCheckLicenseFileHeaders(t *testing.T) {
+	dir := t.TempDir()
+	fp := filepath.Join(dir, "LICENSE")
+	srcDir := filepath.Join(dir, "src")
+
+	// The sources have Apache license headers, and the vendored ones are
+	// skipped.
+	for _, entry := range []struct {
+		path        string
+		licenseType string
+	}{
+		{filepath.Join(srcDir, "main.go"), "apache"},
+		{filepath.Join(srcDir, "pkg", "app.py"), "apache"},
+		{filepath.Join(srcDir, "vendor", "example.com", "foo", "foo.go"), "mit"},
+	} {
+		writeTestFile(t, entry.path, "package main\n")
+		h := newTestLicenseHeader(t, entry.path, "Paul Greenberg")
+		if err := h.AddLicenseType(entry.licenseType); err != nil {
+			t.Fatal(err)
+		}
+		if err := AddLicense(h); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeTestFile(t, filepath.Join(srcDir, "README.md"), "Licensed under the MIT License\n")
+
+	for i, test := range []struct {
+		licenseType string
+		shouldErr   bool
+	}{
+		{licenseType: "mit", shouldErr: true},
+		{licenseType: "apache"},
+	} {
+		h := newTestLicenseHeader(t, "", "Paul Greenberg")
+		if err := h.AddLicenseType(test.licenseType); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteLicenseFile(h, fp); err != nil {
+			t.Fatal(err)
+		}
+		licenseType, err := CheckLicenseFileHeaders(fp, srcDir)
+		if licenseType != "apache" {
+			t.Fatalf("FAIL: test %d: header license type mismatch: %q (actual) vs. %q (expected)", i, licenseType, "apache")
+		}
+		if test.shouldErr && err == nil {
+			t.Fatalf("FAIL: test %d: expected %s vs. apache license type mismatch, got success", i, test.licenseType)
+		}
+		if !test.shouldErr && err != nil {
+			t.Fatalf("FAIL: test %d: unexpected error: %v", i, err)
+		}
+	}
+
+	// The headers of different license types.
+	mitFile := filepath.Join(srcDir, "mit.go")
+	writeTestFile(t, mitFile, "package main\n")
+	h := newTestLicenseHeader(t, mitFile, "Paul Greenberg")
+	if err := h.AddLicenseType("mit"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddLicense(h); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DetectHeaderLicenseType(srcDir); err == nil {
+		t.Fatalf("FAIL: expected multiple license types error, got success")
+	}
+	if _, err := CheckLicenseFileHeaders(fp, t.TempDir()); err == nil {
+		t.Fatalf("FAIL: expected no license headers error, got success")
+	}
+}
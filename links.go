@@ -0,0 +1,224 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	reInlineLink    = regexp.MustCompile(`\]\(\s*(<[^>]*>|[^)\s]+)`)
+	reLinkReference = regexp.MustCompile(`^\s{0,3}\[[^\]]+\]:\s*(<[^>]*>|\S+)`)
+	reCodeSpan      = regexp.MustCompile("`[^`]*`")
+	reURLScheme     = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*:`)
+	reHTMLAnchor    = regexp.MustCompile(`<[A-Za-z][^>]*?\s(?:id|name)\s*=\s*["']([^"']+)["']`)
+)
+
+// BrokenLink is a link to a file or a heading that does not exist.
+type BrokenLink struct {
+	FilePath string `json:"file_path" xml:"file_path" yaml:"file_path"`
+	Line     int    `json:"line" xml:"line" yaml:"line"`
+	Target   string `json:"target" xml:"target" yaml:"target"`
+	Reason   string `json:"reason" xml:"reason" yaml:"reason"`
+}
+
+// String returns the location of the broken link, its target, and the
+// reason.
+func (l *BrokenLink) String() string {
+	return fmt.Sprintf("%s:%d: broken link to %q: %s", l.FilePath, l.Line, l.Target, l.Reason)
+}
+
+// LinkCheck holds the Markdown documents whose links get checked.
+type LinkCheck struct {
+	FilePaths []string
+	// RootDir is the directory the links starting with a slash are
+	// relative to.
+	RootDir string
+	// SlugStyle is the platform whose heading anchors the links follow,
+	// i.e. github, gitlab, bitbucket, or hugo.
+	SlugStyle   string
+	BrokenLinks []*BrokenLink
+	anchors     map[string]map[string]bool
+}
+
+// NewLinkCheck returns an instance of LinkCheck.
+func NewLinkCheck() *LinkCheck {
+	return &LinkCheck{
+		RootDir:   ".",
+		SlugStyle: "github",
+	}
+}
+
+// AddFilePath adds a Markdown document.
+func (c *LinkCheck) AddFilePath(s string) error {
+	if s == "" {
+		return fmt.Errorf("file path is empty")
+	}
+	c.FilePaths = append(c.FilePaths, s)
+	return nil
+}
+
+// AddSlugStyle adds the platform whose heading anchors the links follow.
+func (c *LinkCheck) AddSlugStyle(s string) error {
+	if _, err := NewSlugger(s); err != nil {
+		return err
+	}
+	c.SlugStyle = s
+	return nil
+}
+
+// CheckLinks checks that the anchor links, e.g. "#install", and relative
+// links, e.g. "docs/usage.md#options", of the documents point to existing
+// files and headings. The links in code blocks and code spans are skipped.
+// The broken links are in the BrokenLinks of the provided LinkCheck.
+func CheckLinks(c *LinkCheck) error {
+	c.BrokenLinks = nil
+	c.anchors = make(map[string]map[string]bool)
+	for _, fp := range c.FilePaths {
+		links, err := c.checkFile(fp)
+		if err != nil {
+			return err
+		}
+		c.BrokenLinks = append(c.BrokenLinks, links...)
+	}
+	if len(c.BrokenLinks) > 0 {
+		return fmt.Errorf("found %d broken links", len(c.BrokenLinks))
+	}
+	return nil
+}
+
+func (c *LinkCheck) checkFile(fp string) ([]*BrokenLink, error) {
+	lines, err := readLines(fp)
+	if err != nil {
+		return nil, err
+	}
+	doc := parseMarkdown(lines)
+	var links []*BrokenLink
+	for i, line := range lines {
+		if doc.literal[i] {
+			continue
+		}
+		line = reCodeSpan.ReplaceAllString(line, "")
+		var targets []string
+		for _, m := range reInlineLink.FindAllStringSubmatch(line, -1) {
+			targets = append(targets, strings.Trim(m[1], "<>"))
+		}
+		if m := reLinkReference.FindStringSubmatch(line); m != nil {
+			targets = append(targets, strings.Trim(m[1], "<>"))
+		}
+		for _, target := range targets {
+			if reason := c.checkLink(fp, target); reason != "" {
+				links = append(links, &BrokenLink{
+					FilePath: fp,
+					Line:     i + 1,
+					Target:   target,
+					Reason:   reason,
+				})
+			}
+		}
+	}
+	return links, nil
+}
+
+// checkLink returns the reason the link is broken, or an empty string when
+// the link resolves. The absolute URLs are not checked.
+func (c *LinkCheck) checkLink(fp, target string) string {
+	if reURLScheme.MatchString(target) || strings.HasPrefix(target, "//") {
+		return ""
+	}
+	p, anchor := target, ""
+	if i := strings.Index(p, "#"); i >= 0 {
+		p, anchor = p[:i], p[i+1:]
+	}
+	if i := strings.Index(p, "?"); i >= 0 {
+		p = p[:i]
+	}
+	if s, err := url.PathUnescape(p); err == nil {
+		p = s
+	}
+	if s, err := url.PathUnescape(anchor); err == nil {
+		anchor = s
+	}
+
+	targetPath := fp
+	if p != "" {
+		if strings.HasPrefix(p, "/") {
+			targetPath = filepath.Join(c.RootDir, filepath.FromSlash(p))
+		} else {
+			targetPath = filepath.Join(filepath.Dir(fp), filepath.FromSlash(p))
+		}
+		fi, err := os.Stat(targetPath)
+		if err != nil {
+			return "file not found"
+		}
+		if fi.IsDir() {
+			return ""
+		}
+	}
+	if anchor == "" {
+		return ""
+	}
+	switch strings.ToLower(filepath.Ext(targetPath)) {
+	case ".md", ".markdown":
+	default:
+		return ""
+	}
+	anchors, err := c.getAnchors(targetPath)
+	if err != nil {
+		return err.Error()
+	}
+	if !anchors[anchor] {
+		return "anchor not found"
+	}
+	return ""
+}
+
+// getAnchors returns the anchors of the headings of a Markdown document
+// and the ones declared with "id" or "name" attributes of HTML tags.
+func (c *LinkCheck) getAnchors(fp string) (map[string]bool, error) {
+	key := filepath.Clean(fp)
+	if anchors, exists := c.anchors[key]; exists {
+		return anchors, nil
+	}
+	lines, err := readLines(fp)
+	if err != nil {
+		return nil, err
+	}
+	slugger, err := NewSlugger(c.SlugStyle)
+	if err != nil {
+		return nil, err
+	}
+	doc := parseMarkdown(lines)
+	anchors := make(map[string]bool)
+	for _, h := range doc.headings {
+		anchors[slugger.Slug(getPlainText(h.title))] = true
+	}
+	for i, line := range lines {
+		if doc.literal[i] {
+			continue
+		}
+		line = reCodeSpan.ReplaceAllString(line, "")
+		for _, m := range reHTMLAnchor.FindAllStringSubmatch(line, -1) {
+			anchors[m[1]] = true
+		}
+	}
+	c.anchors[key] = anchors
+	return anchors, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckLinks(t *testing.T) {
+	dir := t.TempDir()
+	readme := filepath.Join(dir, "README.md")
+	writeTestFile(t, readme, strings.Join([]string{
+		"# Project",
+		"",
+		"* [Install](#install)",
+		"* [Old Name](#setup)",
+		"* [Second Usage](#usage-1)",
+		"* [Third Usage](#usage-2)",
+		"* [Explicit](#custom-anchor) and [named](#legacy)",
+		"* [Options](docs/usage.md#options) and [Missing](docs/usage.md#flags)",
+		"* [Site](https://example.com/#nowhere) and [Image](logo.png#x)",
+		"* [Gone](docs/gone.md)",
+		"",
+		"Use `[code](#not-checked)` here.",
+		"",
+		"```",
+		"[block](#not-checked)",
+		"```",
+		"",
+		"## Install",
+		"",
+		"## Usage",
+		"",
+		"## Usage",
+		"",
+		`<a name="legacy"></a>`,
+		`<div id="custom-anchor">`,
+		"",
+		"[ref]: #nothing",
+	}, "\n"))
+	writeTestFile(t, filepath.Join(dir, "docs", "usage.md"), "# Usage\n\n## Options\n\nBack to [install](../README.md#install).\n")
+	writeTestFile(t, filepath.Join(dir, "logo.png"), "")
+
+	c := NewLinkCheck()
+	if err := c.AddFilePath(readme); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if err := c.AddFilePath(filepath.Join(dir, "docs", "usage.md")); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	err := CheckLinks(c)
+	if err == nil || err.Error() != "found 5 broken links" {
+		t.Fatalf("FAIL: unexpected error: %v", err)
+	}
+	var actual []string
+	for _, l := range c.BrokenLinks {
+		actual = append(actual, fmt.Sprintf("%d:%s:%s", l.Line, l.Target, l.Reason))
+	}
+	expected := []string{
+		"4:#setup:anchor not found",
+		"6:#usage-2:anchor not found",
+		"8:docs/usage.md#flags:anchor not found",
+		"10:docs/gone.md:file not found",
+		"27:#nothing:anchor not found",
+	}
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("FAIL: broken links mismatch:\n%s\n(actual) vs.\n%s\n(expected)", strings.Join(actual, "\n"), strings.Join(expected, "\n"))
+	}
+
+	// The anchors follow the configured platform.
+	c = NewLinkCheck()
+	c.AddFilePath(filepath.Join(dir, "docs", "usage.md"))
+	if err := c.AddSlugStyle("bitbucket"); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if err := CheckLinks(c); err == nil {
+		t.Fatalf("FAIL: expected broken link for bitbucket anchors")
+	}
+	if err := c.AddSlugStyle("unknown"); err == nil {
+		t.Fatalf("FAIL: expected error for unsupported slug style")
+	}
+}
@@ -0,0 +1,287 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"regexp"
+	"strings"
+)
+
+const asciiLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+var (
+	reSetextH1      = regexp.MustCompile(`^=+\s*$`)
+	reSetextH2      = regexp.MustCompile(`^-+\s*$`)
+	reThematicBreak = regexp.MustCompile(`^((\*\s*){3,}|(-\s*){3,}|(_\s*){3,})$`)
+	reListItem      = regexp.MustCompile(`^([-*+]|\d{1,9}[.)])(\s|$)`)
+	reHTMLTag       = regexp.MustCompile(`^(<[A-Za-z][A-Za-z0-9-]*(\s+[^<>]*)?/?>|</[A-Za-z][A-Za-z0-9-]*\s*>)\s*$`)
+	reHTMLBlockTag  = regexp.MustCompile(`(?i)^</?(address|article|aside|base|basefont|blockquote|body|caption|center|col|colgroup|dd|details|dialog|dir|div|dl|dt|fieldset|figcaption|figure|footer|form|frame|frameset|h[1-6]|head|header|hr|html|iframe|legend|li|link|main|menu|menuitem|nav|noframes|ol|optgroup|option|p|param|search|section|summary|table|tbody|td|tfoot|th|thead|title|tr|track|ul)(\s|/?>|$)`)
+	reHTMLRawTag    = regexp.MustCompile(`(?i)^<(script|pre|style|textarea)(\s|>|$)`)
+)
+
+// parsedHeading is a heading found in a document.
+type parsedHeading struct {
+	level int
+	title string
+	// line is the index of the first line of the heading. For Setext
+	// headings, it is the first line of the text.
+	line int
+	// text is the index of the first line with the heading text.
+	text int
+	// underline is the index of the line with Setext heading underline.
+	underline int
+	// id is the explicit anchor of the heading, if any.
+	id string
+}
+
+// parsedDocument holds the lines of a document and the results of the
+// scan of the lines.
+type parsedDocument struct {
+	lines    []string
+	headings []*parsedHeading
+	// literal is true for the lines of front matter and code blocks.
+	literal []bool
+}
+
+// parseMarkdown scans the lines of a Markdown document for ATX and Setext
+// headings. It skips front matter, fenced and indented code blocks, and
+// HTML blocks.
+func parseMarkdown(lines []string) *parsedDocument {
+	doc := &parsedDocument{
+		lines:   lines,
+		literal: make([]bool, len(lines)),
+	}
+
+	var paragraph []string
+	var paragraphIndex int
+	var fence string
+	var htmlEnd string
+	var inHTML bool
+
+	start := skipFrontMatter(lines)
+	for i := 0; i < start; i++ {
+		doc.literal[i] = true
+	}
+
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		indent, s := getIndent(line)
+
+		if fence != "" {
+			doc.literal[i] = true
+			if indent < 4 && strings.HasPrefix(s, fence) && strings.Trim(s, fence[:1]+" \t") == "" {
+				fence = ""
+			}
+			continue
+		}
+
+		if inHTML {
+			if htmlEnd == "" {
+				if strings.TrimSpace(line) == "" {
+					inHTML = false
+				}
+				continue
+			}
+			if strings.Contains(strings.ToLower(line), htmlEnd) {
+				inHTML = false
+			}
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			paragraph = nil
+			continue
+		}
+
+		if indent >= 4 {
+			if len(paragraph) > 0 {
+				// Paragraph continuation.
+				paragraph = append(paragraph, s)
+				continue
+			}
+			doc.literal[i] = true
+			continue
+		}
+
+		if f := getFence(s); f != "" {
+			paragraph = nil
+			fence = f
+			doc.literal[i] = true
+			continue
+		}
+
+		if level, title := getATXHeading(s); level > 0 {
+			paragraph = nil
+			doc.headings = append(doc.headings, &parsedHeading{
+				level: level,
+				title: title,
+				line:  i,
+				text:  i,
+			})
+			continue
+		}
+
+		if len(paragraph) > 0 && (reSetextH1.MatchString(s) || reSetextH2.MatchString(s)) {
+			level := 1
+			if strings.HasPrefix(s, "-") {
+				level = 2
+			}
+			doc.headings = append(doc.headings, &parsedHeading{
+				level:     level,
+				title:     strings.TrimSpace(strings.Join(paragraph, " ")),
+				line:      paragraphIndex,
+				text:      paragraphIndex,
+				underline: i,
+			})
+			paragraph = nil
+			continue
+		}
+
+		if reThematicBreak.MatchString(s) {
+			paragraph = nil
+			continue
+		}
+
+		if strings.HasPrefix(s, "<") {
+			if end, found := getHTMLBlockEnd(s, len(paragraph) > 0); found {
+				paragraph = nil
+				inHTML = true
+				htmlEnd = end
+				if end != "" && strings.Contains(strings.ToLower(s), end) {
+					inHTML = false
+				}
+				continue
+			}
+		}
+
+		if strings.HasPrefix(s, ">") || reListItem.MatchString(s) {
+			// The content of containers, i.e. block quotes and list
+			// items, does not form Setext headings.
+			paragraph = nil
+			continue
+		}
+
+		if len(paragraph) == 0 {
+			paragraphIndex = i
+		}
+		paragraph = append(paragraph, s)
+	}
+	return doc
+}
+
+// skipFrontMatter returns the index of the first line after YAML or TOML
+// front matter, or zero when the document has no front matter.
+func skipFrontMatter(lines []string) int {
+	if len(lines) == 0 {
+		return 0
+	}
+	var ends []string
+	switch strings.TrimSpace(lines[0]) {
+	case "---":
+		ends = []string{"---", "..."}
+	case "+++":
+		ends = []string{"+++"}
+	default:
+		return 0
+	}
+	for i := 1; i < len(lines); i++ {
+		for _, end := range ends {
+			if strings.TrimSpace(lines[i]) == end {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// getIndent returns the width of the indentation of a line, with tabs
+// expanded to four spaces, and the line without the indentation.
+func getIndent(line string) (int, string) {
+	var indent int
+	for i, c := range line {
+		switch c {
+		case ' ':
+			indent++
+		case '\t':
+			indent += 4 - indent%4
+		default:
+			return indent, line[i:]
+		}
+	}
+	return indent, ""
+}
+
+// getFence returns the opening code fence, e.g. ``` or ~~~~, found at
+// the beginning of the provided string.
+func getFence(s string) string {
+	for _, c := range []string{"`", "~"} {
+		n := len(s) - len(strings.TrimLeft(s, c))
+		if n < 3 {
+			continue
+		}
+		if c == "`" && strings.Contains(s[n:], "`") {
+			// The info string of backtick fence cannot contain backticks.
+			return ""
+		}
+		return s[:n]
+	}
+	return ""
+}
+
+// getATXHeading returns the level and the text of ATX heading, e.g.
+// "## Heading ##", or zero level when the string is not a heading.
+func getATXHeading(s string) (int, string) {
+	level := len(s) - len(strings.TrimLeft(s, "#"))
+	if level == 0 || level > 6 {
+		return 0, ""
+	}
+	if len(s) > level && s[level] != ' ' && s[level] != '\t' {
+		return 0, ""
+	}
+	title := strings.TrimSpace(s[level:])
+	// Remove optional closing sequence.
+	if trimmed := strings.TrimRight(title, "#"); trimmed != title {
+		if trimmed == "" {
+			title = ""
+		} else if strings.HasSuffix(trimmed, " ") || strings.HasSuffix(trimmed, "\t") {
+			title = strings.TrimSpace(trimmed)
+		}
+	}
+	return level, title
+}
+
+// getHTMLBlockEnd determines whether the provided string starts HTML
+// block. It returns the string ending the block, or an empty string when
+// the block ends with a blank line.
+func getHTMLBlockEnd(s string, inParagraph bool) (string, bool) {
+	if m := reHTMLRawTag.FindStringSubmatch(s); m != nil {
+		return "</" + strings.ToLower(m[1]) + ">", true
+	}
+	switch {
+	case strings.HasPrefix(s, "<!--"):
+		return "-->", true
+	case strings.HasPrefix(s, "<?"):
+		return "?>", true
+	case strings.HasPrefix(s, "<![CDATA["):
+		return "]]>", true
+	case len(s) > 2 && strings.HasPrefix(s, "<!") && strings.ContainsRune(asciiLetters, rune(s[2])):
+		return ">", true
+	case reHTMLBlockTag.MatchString(s):
+		return "", true
+	case !inParagraph && reHTMLTag.MatchString(s):
+		return "", true
+	}
+	return "", false
+}
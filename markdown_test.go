@@ -0,0 +1,91 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const testMarkdownDocument = `---
+title: Front Matter
+# not a heading
+---
+
+Title
+=====
+
+## Install ##
+
+` + "```bash" + `
+# install the package
+## not a heading
+` + "```" + `
+
+~~~~
+` + "```" + `
+# still code
+~~~~
+
+    # indented code
+
+<details>
+## inside html block
+</details>
+
+<!--
+## inside comment
+-->
+
+Multi-line
+Setext heading
+--------------
+
+- list item
+---
+
+#hashtag
+
+  ### Indented ATX #
+
+####### Too deep
+`
+
+func TestParseMarkdown(t *testing.T) {
+	doc := parseMarkdown(strings.Split(testMarkdownDocument, "\n"))
+	var got []string
+	for _, h := range doc.headings {
+		got = append(got, fmt.Sprintf("%d:%d:%s", h.line, h.level, h.title))
+	}
+	expected := []string{
+		"5:1:Title",
+		"8:2:Install",
+		"30:2:Multi-line Setext heading",
+		"39:3:Indented ATX",
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("FAIL: headings mismatch:\n>>>got:\n%s\n>>>expected:\n%s",
+			strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+	for i, literal := range []bool{true, true, true, true, false} {
+		if doc.literal[i] != literal {
+			t.Fatalf("FAIL: line %d: expected literal %t", i, literal)
+		}
+	}
+	if !doc.literal[11] || !doc.literal[16] || !doc.literal[20] {
+		t.Fatal("FAIL: expected code block lines to be literal")
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const rstAdornmentChars = "!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~"
+
+// rstFormat is reStructuredText document format. The table of contents
+// links the sections with implicit hyperlink references, e.g. `Install`_.
+type rstFormat struct{}
+
+func (rstFormat) markers() [][]string {
+	return [][]string{{".. begin-toc", ".. end-toc"}}
+}
+
+func (rstFormat) ignoreMarker() string {
+	return ".. toc-ignore"
+}
+
+func (rstFormat) ignoreMarkerSkipsBlank() bool {
+	return true
+}
+
+func (rstFormat) slugger() Slugger {
+	return nil
+}
+
+func (rstFormat) hasTitleAnchor() bool {
+	return false
+}
+
+func (rstFormat) parse(lines []string) *parsedDocument {
+	return parseRST(lines)
+}
+
+func (rstFormat) renderTitle(title string, level int) []string {
+	// The rubric is an informal heading, it does not start a section.
+	return []string{"", ".. rubric:: " + title}
+}
+
+func (rstFormat) renderEntries(entries []*tocEntry, minDepth int, sep string, ordered bool) []string {
+	indent := "  "
+	if ordered {
+		// The items of auto-enumerated lists start with "#.".
+		sep, indent = "#.", "   "
+	}
+	lines := []string{""}
+	for _, h := range entries {
+		offsetDepth := h.depth - minDepth
+		// The nested lists are separated by blank lines.
+		ref := "`" + h.title + "`_"
+		if h.name != h.title {
+			// The text differs from the section title.
+			ref = "`" + h.title + " <" + h.name + "_>`_"
+		}
+		lines = append(lines, strings.Repeat(indent, offsetDepth)+sep+" "+ref, "")
+	}
+	return lines
+}
+
+func (rstFormat) setHeadingNumber(lines []string, h *parsedHeading, number string) {
+	title := number + " " + stripSectionNumber(strings.TrimSpace(lines[h.text]))
+	lines[h.text] = title
+	// The adornments are at least as long as the title, and the overline
+	// matches the underline.
+	underline := strings.TrimRight(lines[h.underline], " \t")
+	if n := utf8.RuneCountInString(title) - utf8.RuneCountInString(underline); n > 0 {
+		underline += strings.Repeat(underline[:1], n)
+	}
+	lines[h.underline] = underline
+	if h.line != h.text {
+		lines[h.line] = underline
+	}
+}
+
+// parseRST scans the lines of a reStructuredText document for section
+// titles, i.e. the text underlined, and optionally overlined, with
+// punctuation characters. The level of a section is determined by the
+// order in which the adornment styles are encountered. The indented
+// blocks following literal block markers (::), directives, and comments
+// are skipped.
+func parseRST(lines []string) *parsedDocument {
+	doc := &parsedDocument{
+		lines:   lines,
+		literal: make([]bool, len(lines)),
+	}
+	var styles []string
+	getLevel := func(style string) int {
+		for i, s := range styles {
+			if s == style {
+				return i + 1
+			}
+		}
+		styles = append(styles, style)
+		return len(styles)
+	}
+
+	prevBlank := true
+	expectLiteral, inLiteral := false, false
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], " \t")
+		if line == "" {
+			prevBlank = true
+			continue
+		}
+		isIndented := line[0] == ' ' || line[0] == '\t'
+		if inLiteral || expectLiteral {
+			if isIndented {
+				inLiteral = true
+				expectLiteral = false
+				doc.literal[i] = true
+				continue
+			}
+			inLiteral, expectLiteral = false, false
+		}
+		if strings.HasPrefix(line, "..") || strings.HasSuffix(line, "::") {
+			expectLiteral = true
+		}
+		if isIndented {
+			prevBlank = false
+			continue
+		}
+
+		if c := getRSTAdornment(line); c != "" && prevBlank && i+2 < len(lines) {
+			title := strings.TrimSpace(lines[i+1])
+			underline := strings.TrimRight(lines[i+2], " \t")
+			if title != "" && underline == line && utf8.RuneCountInString(line) >= utf8.RuneCountInString(title) {
+				doc.headings = append(doc.headings, &parsedHeading{
+					level:     getLevel("o" + c),
+					title:     title,
+					line:      i,
+					text:      i + 1,
+					underline: i + 2,
+				})
+				i += 2
+				prevBlank = false
+				continue
+			}
+		}
+
+		if prevBlank && getRSTAdornment(line) == "" && i+1 < len(lines) {
+			underline := strings.TrimRight(lines[i+1], " \t")
+			if c := getRSTAdornment(underline); c != "" && utf8.RuneCountInString(underline) >= utf8.RuneCountInString(line) {
+				doc.headings = append(doc.headings, &parsedHeading{
+					level:     getLevel("u" + c),
+					title:     line,
+					line:      i,
+					text:      i,
+					underline: i + 1,
+				})
+				i++
+				prevBlank = false
+				continue
+			}
+		}
+		prevBlank = false
+	}
+	return doc
+}
+
+// getRSTAdornment returns the character of a section title adornment,
+// i.e. a line of at least two repeated punctuation characters, or an
+// empty string when the line is not an adornment.
+func getRSTAdornment(s string) string {
+	if len(s) < 2 || !strings.ContainsRune(rstAdornmentChars, rune(s[0])) {
+		return ""
+	}
+	if strings.Trim(s, s[:1]) != "" {
+		return ""
+	}
+	return s[:1]
+}
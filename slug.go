@@ -0,0 +1,212 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	// wordRanges are the characters of Ruby's \p{Word} class, i.e.
+	// alphabetic characters, marks, decimal numbers, connector punctuation,
+	// and join controls. The alphabetic characters are the letters, letter
+	// numbers, e.g. Roman numerals, and the other alphabetic characters,
+	// e.g. circled letters.
+	wordRanges = []*unicode.RangeTable{
+		unicode.L, unicode.Nl, unicode.Other_Alphabetic, unicode.M,
+		unicode.Nd, unicode.Pc, unicode.Join_Control,
+	}
+	reDashes     = regexp.MustCompile(`-+`)
+	reDigits     = regexp.MustCompile(`^\d+$`)
+	reNonWord    = regexp.MustCompile(`[^\w\s-]`)
+	reSeparators = regexp.MustCompile(`[-\s]+`)
+	reIDCount    = regexp.MustCompile(`^(.*)_([0-9]+)$`)
+	reImage      = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	reLink       = regexp.MustCompile(`\[([^\]]*)\](\([^)]*\)|\[[^\]]*\])`)
+	reTag        = regexp.MustCompile(`<[^>]+>`)
+
+	// accents maps the letters with diacritics to their base letters,
+	// i.e. the first character of NFKD decomposition.
+	accents = map[rune]string{}
+)
+
+func init() {
+	for base, letters := range map[string]string{
+		"A": "ÀÁÂÃÄÅĀĂĄ", "a": "àáâãäåāăą", "C": "ÇĆĈĊČ", "c": "çćĉċč",
+		"D": "Ď", "d": "ď", "E": "ÈÉÊËĒĔĖĘĚ", "e": "èéêëēĕėęě",
+		"G": "ĜĞĠĢ", "g": "ĝğġģ", "H": "Ĥ", "h": "ĥ", "I": "ÌÍÎÏĨĪĬĮİ",
+		"i": "ìíîïĩīĭį", "J": "Ĵ", "j": "ĵ", "K": "Ķ", "k": "ķ", "L": "ĹĻĽĿ",
+		"l": "ĺļľŀ", "N": "ÑŃŅŇ", "n": "ñńņň", "O": "ÒÓÔÕÖŌŎŐ", "o": "òóôõöōŏő",
+		"R": "ŔŖŘ", "r": "ŕŗř", "S": "ŚŜŞŠ", "s": "śŝşš", "T": "ŢŤ", "t": "ţť",
+		"U": "ÙÚÛÜŨŪŬŮŰŲ", "u": "ùúûüũūŭůűų", "W": "Ŵ", "w": "ŵ", "Y": "ÝŶŸ",
+		"y": "ýÿŷ", "Z": "ŹŻŽ", "z": "źżž",
+	} {
+		for _, c := range letters {
+			accents[c] = base
+		}
+	}
+}
+
+// Slugger generates the anchors of headings the way a Markdown rendering
+// platform does.
+type Slugger interface {
+	// Slug returns the anchor, without leading #, for the text of a
+	// heading. The anchors of duplicate headings get numbered.
+	Slug(s string) string
+}
+
+// NewSlugger returns Slugger for a platform, i.e. github, gitlab,
+// bitbucket, or hugo.
+func NewSlugger(s string) (Slugger, error) {
+	switch s {
+	case "github", "":
+		return &githubSlugger{occurrences: make(map[string]int)}, nil
+	case "gitlab":
+		return &gitlabSlugger{headers: make(map[string]int)}, nil
+	case "bitbucket":
+		return &bitbucketSlugger{ids: make(map[string]bool)}, nil
+	case "hugo":
+		return &hugoSlugger{ids: make(map[string]bool)}, nil
+	}
+	return nil, fmt.Errorf("slug style %q is unsupported", s)
+}
+
+// getPlainText returns the text of a heading as rendered, i.e. without
+// images, links, inline code, and HTML tags.
+func getPlainText(s string) string {
+	s = reImage.ReplaceAllString(s, "$1")
+	s = reLink.ReplaceAllString(s, "$1")
+	s = reTag.ReplaceAllString(s, "")
+	return strings.TrimSpace(strings.ReplaceAll(s, "`", ""))
+}
+
+// removePunctuation removes the characters outside of Ruby's \p{Word}
+// class, hyphens and spaces, i.e. the punctuation regex of GitHub and
+// GitLab.
+func removePunctuation(s string) string {
+	return strings.Map(func(c rune) rune {
+		if c == '-' || c == ' ' || unicode.In(c, wordRanges...) {
+			return c
+		}
+		return -1
+	}, s)
+}
+
+// githubSlugger follows github-slugger.
+type githubSlugger struct {
+	occurrences map[string]int
+}
+
+func (g *githubSlugger) Slug(s string) string {
+	s = strings.ToLower(s)
+	s = removePunctuation(s)
+	s = strings.ReplaceAll(s, " ", "-")
+	slug := s
+	for {
+		if _, exists := g.occurrences[slug]; !exists {
+			break
+		}
+		g.occurrences[s]++
+		slug = s + "-" + strconv.Itoa(g.occurrences[s])
+	}
+	g.occurrences[slug] = 0
+	return slug
+}
+
+// gitlabSlugger follows GitLab's table of contents filter.
+type gitlabSlugger struct {
+	headers map[string]int
+}
+
+func (g *gitlabSlugger) Slug(s string) string {
+	s = strings.ToLower(s)
+	s = removePunctuation(s)
+	s = strings.ReplaceAll(s, " ", "-")
+	s = reDashes.ReplaceAllString(s, "-")
+	if reDigits.MatchString(s) {
+		// Digits-only anchors conflict with issue references.
+		s = "anchor-" + s
+	}
+	slug := s
+	if g.headers[s] > 0 {
+		slug = s + "-" + strconv.Itoa(g.headers[s])
+	}
+	g.headers[s]++
+	return slug
+}
+
+// bitbucketSlugger follows Bitbucket's "markdown-header-" anchors produced
+// by Python-Markdown's slugify and unique functions.
+type bitbucketSlugger struct {
+	ids map[string]bool
+}
+
+func (b *bitbucketSlugger) Slug(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if base, exists := accents[c]; exists {
+			sb.WriteString(base)
+			continue
+		}
+		if c < unicode.MaxASCII {
+			sb.WriteRune(c)
+		}
+	}
+	s = reNonWord.ReplaceAllString(sb.String(), "")
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = reSeparators.ReplaceAllString(s, "-")
+	slug := "markdown-header-" + s
+	for b.ids[slug] {
+		if m := reIDCount.FindStringSubmatch(slug); m != nil {
+			i, _ := strconv.Atoi(m[2])
+			slug = m[1] + "_" + strconv.Itoa(i+1)
+			continue
+		}
+		slug = slug + "_1"
+	}
+	b.ids[slug] = true
+	return slug
+}
+
+// hugoSlugger follows Hugo's "github" auto heading ID type.
+type hugoSlugger struct {
+	ids map[string]bool
+}
+
+func (h *hugoSlugger) Slug(s string) string {
+	var sb strings.Builder
+	for _, c := range strings.TrimSpace(s) {
+		switch {
+		case c == '-' || c == ' ':
+			sb.WriteRune('-')
+		case c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c):
+			sb.WriteRune(unicode.ToLower(c))
+		}
+	}
+	s = sb.String()
+	if s == "" {
+		s = "heading"
+	}
+	slug := s
+	for i := 1; h.ids[slug]; i++ {
+		slug = s + "-" + strconv.Itoa(i)
+	}
+	h.ids[slug] = true
+	return slug
+}
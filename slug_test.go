@@ -0,0 +1,85 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestSlugCorpus(t *testing.T) {
+	b, err := ioutil.ReadFile("testdata/slugs.json")
+	if err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	var corpus []map[string]interface{}
+	if err := json.Unmarshal(b, &corpus); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	for _, entry := range corpus {
+		for _, style := range []string{"github", "gitlab", "bitbucket", "hugo"} {
+			toc := NewTableOfContents()
+			if err := toc.AddSlugStyle(style); err != nil {
+				t.Fatalf("FAIL: %v", err)
+			}
+			headings := entry["headings"].([]interface{})
+			expected := entry[style].([]interface{})
+			if len(headings) != len(expected) {
+				t.Fatalf("FAIL: %s (%s): corpus has %d headings and %d slugs", entry["name"], style, len(headings), len(expected))
+			}
+			for i, heading := range headings {
+				link := toc.getLink(heading.(string))
+				if link != "#"+expected[i].(string) {
+					t.Fatalf("FAIL: %s (%s): heading %q: %s (actual) vs. #%s (expected)", entry["name"], style, heading, link, expected[i])
+				}
+			}
+		}
+	}
+
+	if err := NewTableOfContents().AddSlugStyle("confluence"); err == nil {
+		t.Fatalf("FAIL: expected error for unsupported slug style")
+	}
+}
+
+// TestSlugFixtures runs the fixtures in the format of github-slugger's
+// test/fixtures.json. Each file is one document, i.e. its headings are
+// slugged in order with one slugger of the platform.
+func TestSlugFixtures(t *testing.T) {
+	for _, style := range []string{"github", "gitlab"} {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", "slugs", style+".json"))
+		if err != nil {
+			t.Fatalf("FAIL: %v", err)
+		}
+		var fixtures []struct {
+			Name     string `json:"name"`
+			Input    string `json:"input"`
+			Expected string `json:"expected"`
+		}
+		if err := json.Unmarshal(b, &fixtures); err != nil {
+			t.Fatalf("FAIL: %s: %v", style, err)
+		}
+		slugger, err := NewSlugger(style)
+		if err != nil {
+			t.Fatalf("FAIL: %v", err)
+		}
+		for _, fixture := range fixtures {
+			if slug := slugger.Slug(fixture.Input); slug != fixture.Expected {
+				t.Fatalf("FAIL: %s: %s: %q: %q (actual) vs. %q (expected)", style, fixture.Name, fixture.Input, slug, fixture.Expected)
+			}
+		}
+	}
+}
@@ -0,0 +1,380 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SyncOptions are the options of the synchronization of the package
+// metadata to a file.
+type SyncOptions struct {
+	// PreRelease omits the git branch and sets the git commit to the
+	// version.
+	PreRelease bool
+	// GoVar is the name of the package level constant or variable, e.g.
+	// Version, holding the version in a Go file. When set, the Go file
+	// does not need to import the versioned package.
+	GoVar string
+}
+
+// Syncer synchronizes the package metadata, e.g. the version, to a file
+// of a particular format.
+type Syncer interface {
+	// Sync updates the file and returns true when the file changed.
+	Sync(fp string, pkg *PackageManager, opts *SyncOptions) (bool, error)
+}
+
+var syncers = make(map[string]Syncer)
+
+func init() {
+	RegisterSyncer(pythonSyncer{}, "python", "py", ".py")
+	RegisterSyncer(blenderSyncer{}, "blender")
+	RegisterSyncer(javascriptSyncer{}, "javascript", "typescript", "js", "ts", ".js", ".ts")
+	RegisterSyncer(golangSyncer{}, "golang", "go", ".go")
+	RegisterSyncer(packageJSONSyncer{}, "npm", "package.json")
+}
+
+// RegisterSyncer registers the syncer by format, e.g. "python", file
+// extension, e.g. ".py", or file name, e.g. "package.json". The file name
+// matches the names ending with it, e.g. "my-package.json". The syncer
+// replaces the one registered with the same key.
+func RegisterSyncer(s Syncer, keys ...string) {
+	for _, k := range keys {
+		syncers[k] = s
+	}
+}
+
+// GetSyncer returns the syncer registered for the format. When the format
+// is empty, the syncer is registered for the name, the longest file name
+// the name ends with, or the extension of the file.
+func GetSyncer(format, fp string) (Syncer, error) {
+	if format != "" {
+		if s, exists := syncers[format]; exists {
+			return s, nil
+		}
+		return nil, fmt.Errorf("sync format %q is unsupported", format)
+	}
+	fileDir, fileName := filepath.Split(fp)
+	if s, exists := syncers[fileName]; exists {
+		return s, nil
+	}
+	// The file names, unlike the formats and the extensions, have a dot
+	// after the first character.
+	var suffix string
+	for k := range syncers {
+		if strings.Index(k, ".") > 0 && strings.HasSuffix(fileName, k) && len(k) > len(suffix) {
+			suffix = k
+		}
+	}
+	if suffix != "" {
+		return syncers[suffix], nil
+	}
+	ext := filepath.Ext(fileName)
+	if s, exists := syncers[strings.ToLower(ext)]; exists {
+		return s, nil
+	}
+	return nil, fmt.Errorf("file %s in %s directory has unsupported file extension %s", fileName, fileDir, ext)
+}
+
+// SyncFile synchronizes the package metadata to the file with the syncer
+// returned by GetSyncer. It returns true when the file changed.
+func SyncFile(fp string, pkg *PackageManager, format string, opts *SyncOptions) (bool, error) {
+	s, err := GetSyncer(format, fp)
+	if err != nil {
+		return false, err
+	}
+	return s.Sync(fp, pkg, opts)
+}
+
+// SourceDate returns the date of the source code for reproducible builds.
+// It is the SOURCE_DATE_EPOCH environment variable, see
+// https://reproducible-builds.org/specs/source-date-epoch/, or the commit
+// time of HEAD of the git repository in the directory.
+func SourceDate(dir string) (time.Time, error) {
+	s := strings.TrimSpace(os.Getenv("SOURCE_DATE_EPOCH"))
+	if s == "" {
+		cmd := exec.Command("git", "log", "-1", "--format=%ct")
+		cmd.Dir = dir
+		b, err := cmd.Output()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to get the commit time in %s: %s", dir, err)
+		}
+		s = strings.TrimSpace(string(b))
+	}
+	epoch, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("source date epoch %q is invalid", s)
+	}
+	return time.Unix(epoch, 0).UTC(), nil
+}
+
+// writeSyncedFile writes the contents to the file, preserving its mode.
+func writeSyncedFile(fp string, b []byte) error {
+	fi, err := os.Stat(fp)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fp, b, fi.Mode().Perm())
+}
+
+// pythonSyncer inspects a Python file for __version__ module level
+// dunder (see PEP 8) and, if necessary, updates the version to
+// match the one found in VERSION file.
+type pythonSyncer struct{}
+
+func (pythonSyncer) Sync(fp string, pkg *PackageManager, opts *SyncOptions) (bool, error) {
+	var buffer bytes.Buffer
+	fh, err := os.Open(fp)
+	if err != nil {
+		return false, err
+	}
+	defer fh.Close()
+
+	isVersionDunderExist := false
+	fileVersion := ""
+	versionDunder := "__version__"
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = line + "\n"
+		if strings.HasPrefix(line, versionDunder) {
+			isVersionDunderExist = true
+			v := strings.SplitN(line, "=", 2)[1]
+			v = strings.TrimSpace(v)
+			v = strings.Replace(v, "'", "", -1)
+			v = strings.Replace(v, "\"", "", -1)
+			fileVersion = v
+			if fileVersion != pkg.Version {
+				buffer.WriteString("__version__ = '" + pkg.Version + "'\n")
+			} else {
+				buffer.WriteString(line)
+			}
+			continue
+		}
+		buffer.WriteString(line)
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	fh.Close()
+	ref := "Please see https://github.com/greenpau/versioned#package-metadata"
+	if !isVersionDunderExist {
+		return false, fmt.Errorf("%s module level dunder not found. %s", versionDunder, ref)
+	}
+	if pkg.Version != fileVersion {
+		return true, writeSyncedFile(fp, buffer.Bytes())
+	}
+	return false, nil
+}
+
+// blenderSyncer inspects a Python file for bl_info["version"] and,
+// if necessary, updates it to match the version found in VERSION file.
+type blenderSyncer struct{}
+
+func (blenderSyncer) Sync(fp string, pkg *PackageManager, opts *SyncOptions) (bool, error) {
+	var buffer bytes.Buffer
+
+	fh, err := os.Open(fp)
+	if err != nil {
+		return false, err
+	}
+	defer fh.Close()
+
+	scanner := bufio.NewScanner(fh)
+
+	inBlInfo := false
+	versionFound := false
+	fileVersion := ""
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		// Detect start of bl_info dictionary
+		if strings.HasPrefix(trimmed, "bl_info") && strings.Contains(trimmed, "{") {
+			inBlInfo = true
+		}
+
+		if inBlInfo && strings.HasPrefix(trimmed, "\"version\"") ||
+			inBlInfo && strings.HasPrefix(trimmed, "'version'") {
+
+			versionFound = true
+
+			// Extract tuple portion: (1, 0, 0)
+			parts := strings.SplitN(line, ":", 2)
+			if len(parts) != 2 {
+				return false, fmt.Errorf("invalid bl_info version format")
+			}
+
+			raw := strings.TrimSpace(parts[1])
+			raw = strings.TrimSuffix(raw, ",")
+			raw = strings.TrimSpace(raw)
+
+			// Convert tuple "(1, 2, 3)" -> "1.2.3"
+			raw = strings.TrimPrefix(raw, "(")
+			raw = strings.TrimSuffix(raw, ")")
+			raw = strings.ReplaceAll(raw, " ", "")
+			fileVersion = strings.ReplaceAll(raw, ",", ".")
+
+			if fileVersion != pkg.Version {
+				// Convert pkg.Version "1.2.3" -> (1, 2, 3)
+				versionParts := strings.Split(pkg.Version, ".")
+				newTuple := "(" + strings.Join(versionParts, ", ") + ")"
+				buffer.WriteString(fmt.Sprintf(`    "version": %s,`+"\n", newTuple))
+			} else {
+				buffer.WriteString(line + "\n")
+			}
+
+			continue
+		}
+
+		// Detect end of bl_info dictionary
+		if inBlInfo && strings.Contains(trimmed, "}") {
+			inBlInfo = false
+		}
+
+		buffer.WriteString(line + "\n")
+	}
+
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+
+	if !versionFound {
+		return false, fmt.Errorf("bl_info['version'] not found")
+	}
+
+	if fileVersion != pkg.Version {
+		return true, writeSyncedFile(fp, buffer.Bytes())
+	}
+
+	return false, nil
+}
+
+// javascriptSyncer inspects a Javascript or Typescript file for the
+// "Version: " property and, if necessary, updates its value.
+type javascriptSyncer struct{}
+
+func (javascriptSyncer) Sync(fp string, pkg *PackageManager, opts *SyncOptions) (bool, error) {
+	var buffer bytes.Buffer
+	fh, err := os.Open(fp)
+	if err != nil {
+		return false, err
+	}
+	defer fh.Close()
+
+	isVersionFound := false
+	fileVersion := ""
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.Contains(line, "Version: ") {
+			isVersionFound = true
+			v := strings.SplitN(line, ":", 2)[1]
+			v = strings.TrimSpace(v)
+			v = strings.Replace(v, ",", "", -1)
+			v = strings.Replace(v, "'", "", -1)
+			v = strings.Replace(v, "\"", "", -1)
+			v = strings.TrimSpace(v)
+			fileVersion = v
+			if fileVersion != pkg.Version {
+				buffer.WriteString(strings.ReplaceAll(line, fileVersion, pkg.Version) + "\n")
+			} else {
+				buffer.WriteString(line + "\n")
+			}
+			continue
+		}
+		buffer.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	fh.Close()
+	ref := "Please see https://github.com/greenpau/versioned#nodejs-javascript-typescript"
+	if !isVersionFound {
+		return false, fmt.Errorf("version not found. %s", ref)
+	}
+	if pkg.Version != fileVersion {
+		return true, writeSyncedFile(fp, buffer.Bytes())
+	}
+	return false, nil
+}
+
+// packageJSONSyncer updates the version field in a Node.js package.json
+// file.
+type packageJSONSyncer struct{}
+
+func (packageJSONSyncer) Sync(fp string, pkg *PackageManager, opts *SyncOptions) (bool, error) {
+	var buffer bytes.Buffer
+	fh, err := os.Open(fp)
+	if err != nil {
+		return false, err
+	}
+	defer fh.Close()
+
+	isVersionFound := false
+	fileVersion := ""
+	// Regex to match "version": "1.2.3" with potential spaces/tabs
+	versionRegex := regexp.MustCompile(`(?i)^(\s*"version"\s*:\s*")([^"]+)("\s*,?\s*)$`)
+
+	scanner := bufio.NewScanner(fh)
+	for scanner.Scan() {
+		line := scanner.Text()
+		matches := versionRegex.FindStringSubmatch(line)
+
+		if len(matches) == 4 {
+			isVersionFound = true
+			prefix := matches[1]
+			fileVersion = matches[2]
+			suffix := matches[3]
+
+			if fileVersion != pkg.Version {
+				buffer.WriteString(prefix + pkg.Version + suffix + "\n")
+			} else {
+				buffer.WriteString(line + "\n")
+			}
+			continue
+		}
+		buffer.WriteString(line + "\n")
+	}
+
+	if err := scanner.Err(); err != nil {
+		return false, err
+	}
+	fh.Close()
+
+	if !isVersionFound {
+		return false, fmt.Errorf("version field not found in %s", fp)
+	}
+
+	if pkg.Version == fileVersion {
+		return false, nil
+	}
+
+	return true, writeSyncedFile(fp, buffer.Bytes())
+}
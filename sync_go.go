@@ -0,0 +1,360 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const versionedPkgPath = "github.com/greenpau/versioned"
+
+// golangSyncer updates the default values, i.e. the second arguments, of
+// the Set* calls, e.g. SetVersion(appVersion, "1.0.0"), of the package
+// managers created with versioned.NewPackageManager in the init functions
+// of a Go file. The build user and date, the description, and the
+// documentation are updated when they are not empty in the package
+// metadata. The missing description and documentation are added after
+// the package manager is created.
+type golangSyncer struct{}
+
+func (golangSyncer) Sync(fp string, pkg *PackageManager, opts *SyncOptions) (bool, error) {
+	if opts == nil {
+		opts = &SyncOptions{}
+	}
+	if opts.GoVar != "" {
+		return syncGoVar(fp, pkg, opts.GoVar)
+	}
+	src, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return false, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, fp, src, parser.ParseComments)
+	if err != nil {
+		return false, err
+	}
+
+	ref := "Please see https://github.com/greenpau/versioned#package-metadata"
+	pkgName := getGoImportName(f, versionedPkgPath)
+	if pkgName == "" {
+		return false, fmt.Errorf("package %s not found", versionedPkgPath)
+	}
+
+	values := map[string]string{
+		"SetVersion":   pkg.Version,
+		"SetGitBranch": pkg.Git.Branch,
+		"SetGitCommit": pkg.Git.Commit,
+	}
+	if opts.PreRelease {
+		values["SetGitBranch"] = ""
+		values["SetGitCommit"] = pkg.Version
+	}
+	if pkg.Build.User != "" {
+		values["SetBuildUser"] = pkg.Build.User
+	}
+	if pkg.Build.Date != "" {
+		values["SetBuildDate"] = pkg.Build.Date
+	}
+	fields := make(map[string]string)
+	if pkg.Description != "" {
+		fields["Description"] = pkg.Description
+	}
+	if pkg.Documentation != "" {
+		fields["Documentation"] = pkg.Documentation
+	}
+
+	// The package managers are the variables, or the fields, assigned the
+	// result of NewPackageManager at package level or in init functions.
+	managers := make(map[string]bool)
+	isNewPackageManager := func(expr ast.Expr) bool {
+		call, ok := expr.(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		return isGoPackageCall(call, pkgName, "NewPackageManager")
+	}
+	var inits []*ast.FuncDecl
+	var managerStmt *ast.AssignStmt
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil && d.Name.Name == "init" && d.Body != nil {
+				inits = append(inits, d)
+			}
+		case *ast.GenDecl:
+			if d.Tok != token.VAR {
+				continue
+			}
+			for _, spec := range d.Specs {
+				vs := spec.(*ast.ValueSpec)
+				for i, v := range vs.Values {
+					if i < len(vs.Names) && isNewPackageManager(v) {
+						managers[vs.Names[i].Name] = true
+					}
+				}
+			}
+		}
+	}
+	for _, fn := range inits {
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			switch s := n.(type) {
+			case *ast.AssignStmt:
+				for i, v := range s.Rhs {
+					if i < len(s.Lhs) && isNewPackageManager(v) {
+						managers[types.ExprString(s.Lhs[i])] = true
+						if managerStmt == nil && len(s.Lhs) == 1 {
+							managerStmt = s
+						}
+					}
+				}
+			case *ast.ValueSpec:
+				for i, v := range s.Values {
+					if i < len(s.Names) && isNewPackageManager(v) {
+						managers[s.Names[i].Name] = true
+					}
+				}
+			}
+			return true
+		})
+	}
+	if len(managers) == 0 {
+		return false, fmt.Errorf("package %s is not initialized. %s", versionedPkgPath, ref)
+	}
+
+	var edits []*goEdit
+	found := make(map[string]bool)
+	for _, fn := range inits {
+		var inspectErr error
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if inspectErr != nil {
+				return false
+			}
+			var name, value string
+			var lit *ast.BasicLit
+			switch s := n.(type) {
+			case *ast.CallExpr:
+				// The calls, e.g. app.SetVersion(appVersion, "1.0.0").
+				sel, ok := s.Fun.(*ast.SelectorExpr)
+				if !ok || len(s.Args) != 2 || !managers[types.ExprString(sel.X)] {
+					return true
+				}
+				name = sel.Sel.Name
+				value, ok = values[name]
+				if !ok {
+					return true
+				}
+				lit, _ = s.Args[1].(*ast.BasicLit)
+			case *ast.AssignStmt:
+				// The assignments, e.g. app.Description = "...".
+				if len(s.Lhs) != 1 || len(s.Rhs) != 1 {
+					return true
+				}
+				sel, ok := s.Lhs[0].(*ast.SelectorExpr)
+				if !ok || !managers[types.ExprString(sel.X)] {
+					return true
+				}
+				name = sel.Sel.Name
+				value, ok = fields[name]
+				if !ok {
+					return true
+				}
+				lit, _ = s.Rhs[0].(*ast.BasicLit)
+			default:
+				return true
+			}
+			if lit == nil || lit.Kind != token.STRING {
+				return true
+			}
+			found[name] = true
+			edit, err := getGoStringEdit(fset, lit, value)
+			if err != nil {
+				inspectErr = err
+				return false
+			}
+			if edit != nil {
+				edits = append(edits, edit)
+			}
+			return true
+		})
+		if inspectErr != nil {
+			return false, inspectErr
+		}
+	}
+	if !found["SetVersion"] {
+		return false, fmt.Errorf("package version not found. %s", ref)
+	}
+	for _, name := range []string{"SetBuildUser", "SetBuildDate"} {
+		if _, exists := values[name]; exists && !found[name] {
+			return false, fmt.Errorf("package %s call not found. %s", name, ref)
+		}
+	}
+	for _, name := range []string{"Description", "Documentation"} {
+		value, exists := fields[name]
+		if !exists || found[name] {
+			continue
+		}
+		if managerStmt == nil {
+			return false, fmt.Errorf("package %s not found. %s", strings.ToLower(name), ref)
+		}
+		start := fset.Position(managerStmt.Pos()).Offset
+		indent := src[bytes.LastIndexByte(src[:start], '\n')+1 : start]
+		edits = append(edits, &goEdit{
+			start: fset.Position(managerStmt.End()).Offset,
+			end:   fset.Position(managerStmt.End()).Offset,
+			text:  "\n" + string(indent) + types.ExprString(managerStmt.Lhs[0]) + "." + name + " = " + strconv.Quote(value),
+		})
+	}
+	if len(edits) == 0 {
+		return false, nil
+	}
+	return true, writeSyncedFile(fp, applyGoEdits(src, edits))
+}
+
+// getGoImportName returns the name the file refers to the imported package
+// by, or an empty string when the package is not imported.
+func getGoImportName(f *ast.File, pkgPath string) string {
+	for _, imp := range f.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err != nil || p != pkgPath {
+			continue
+		}
+		if imp.Name != nil {
+			return imp.Name.Name
+		}
+		return pkgPath[strings.LastIndex(pkgPath, "/")+1:]
+	}
+	return ""
+}
+
+// isGoPackageCall returns true when the call is the function of the
+// package, e.g. versioned.NewPackageManager(...). The functions of the
+// package imported with "." are called without the package name.
+func isGoPackageCall(call *ast.CallExpr, pkgName, funcName string) bool {
+	switch fn := call.Fun.(type) {
+	case *ast.SelectorExpr:
+		x, ok := fn.X.(*ast.Ident)
+		return ok && x.Name == pkgName && fn.Sel.Name == funcName
+	case *ast.Ident:
+		return pkgName == "." && fn.Name == funcName
+	}
+	return false
+}
+
+// goEdit replaces the source code between the offsets.
+type goEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// applyGoEdits returns the source code with the edits applied. The code
+// outside of the edits, including formatting and comments, is intact.
+func applyGoEdits(src []byte, edits []*goEdit) []byte {
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].start < edits[j].start
+	})
+	var b bytes.Buffer
+	offset := 0
+	for _, e := range edits {
+		b.Write(src[offset:e.start])
+		b.WriteString(e.text)
+		offset = e.end
+	}
+	b.Write(src[offset:])
+	return b.Bytes()
+}
+
+// getGoStringEdit returns the edit replacing the string literal with the
+// value, or nil when the literal has the value. The raw string literals
+// stay raw.
+func getGoStringEdit(fset *token.FileSet, lit *ast.BasicLit, value string) (*goEdit, error) {
+	s, err := strconv.Unquote(lit.Value)
+	if err != nil {
+		return nil, err
+	}
+	if s == value {
+		return nil, nil
+	}
+	text := strconv.Quote(value)
+	if strings.HasPrefix(lit.Value, "`") && !strings.Contains(value, "`") {
+		text = "`" + value + "`"
+	}
+	return &goEdit{
+		start: fset.Position(lit.Pos()).Offset,
+		end:   fset.Position(lit.End()).Offset,
+		text:  text,
+	}, nil
+}
+
+// syncGoVar updates the string value of the package level constant or
+// variable, e.g. const Version = "1.0.0", to the version. The file does
+// not need to import the versioned package.
+func syncGoVar(fp string, pkg *PackageManager, name string) (bool, error) {
+	src, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return false, err
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, fp, src, parser.ParseComments)
+	if err != nil {
+		return false, err
+	}
+	var edits []*goEdit
+	found := false
+	for _, decl := range f.Decls {
+		gd, ok := decl.(*ast.GenDecl)
+		if !ok || (gd.Tok != token.CONST && gd.Tok != token.VAR) {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			vs := spec.(*ast.ValueSpec)
+			for i, ident := range vs.Names {
+				if ident.Name != name {
+					continue
+				}
+				found = true
+				var lit *ast.BasicLit
+				if i < len(vs.Values) {
+					lit, _ = vs.Values[i].(*ast.BasicLit)
+				}
+				if lit == nil || lit.Kind != token.STRING {
+					return false, fmt.Errorf("go %s %s in %s is not a string literal", gd.Tok, name, fp)
+				}
+				edit, err := getGoStringEdit(fset, lit, pkg.Version)
+				if err != nil {
+					return false, err
+				}
+				if edit != nil {
+					edits = append(edits, edit)
+				}
+			}
+		}
+	}
+	if !found {
+		return false, fmt.Errorf("go constant or variable %s not found in %s", name, fp)
+	}
+	if len(edits) == 0 {
+		return false, nil
+	}
+	return true, writeSyncedFile(fp, applyGoEdits(src, edits))
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"text/template"
+)
+
+// RegexSyncer synchronizes the version to the files of any format, e.g.
+// Dockerfile or C header, with a regular expression. The text of the
+// capture group named "version", or the first capture group, in every
+// match of the pattern is replaced with the rendered template.
+type RegexSyncer struct {
+	Pattern  *regexp.Regexp
+	Template *template.Template
+	group    int
+}
+
+// syncTemplateData is the data of the templates of RegexSyncer, e.g.
+// "{{.Major}}.{{.Minor}}".
+type syncTemplateData struct {
+	Name    string
+	Version string
+	Major   uint64
+	Minor   uint64
+	Patch   uint64
+	Branch  string
+	Commit  string
+}
+
+// NewRegexSyncer returns an instance of RegexSyncer. The pattern must
+// have a capture group. The template is text/template with the Name,
+// Version, Major, Minor, Patch, Branch, and Commit fields. When the
+// template is empty, it is "{{.Version}}".
+func NewRegexSyncer(pattern, tmpl string) (*RegexSyncer, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("sync pattern %q is invalid: %s", pattern, err)
+	}
+	if re.NumSubexp() == 0 {
+		return nil, fmt.Errorf("sync pattern %q has no capture group", pattern)
+	}
+	if tmpl == "" {
+		tmpl = "{{.Version}}"
+	}
+	t, err := template.New("sync").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("sync template %q is invalid: %s", tmpl, err)
+	}
+	s := &RegexSyncer{
+		Pattern:  re,
+		Template: t,
+		group:    1,
+	}
+	if i := re.SubexpIndex("version"); i > 0 {
+		s.group = i
+	}
+	return s, nil
+}
+
+// Sync replaces the captured text in the matches of the pattern.
+func (s *RegexSyncer) Sync(fp string, pkg *PackageManager, opts *SyncOptions) (bool, error) {
+	major, minor, patch, err := parseVersion(pkg.Version)
+	if err != nil {
+		return false, err
+	}
+	data := &syncTemplateData{
+		Name:    pkg.Name,
+		Version: pkg.Version,
+		Major:   major,
+		Minor:   minor,
+		Patch:   patch,
+		Branch:  pkg.Git.Branch,
+		Commit:  pkg.Git.Commit,
+	}
+	var value bytes.Buffer
+	if err := s.Template.Execute(&value, data); err != nil {
+		return false, err
+	}
+
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		return false, err
+	}
+	matches := s.Pattern.FindAllSubmatchIndex(b, -1)
+	if len(matches) == 0 {
+		return false, fmt.Errorf("sync pattern %q not found in %s", s.Pattern, fp)
+	}
+	var buffer bytes.Buffer
+	offset := 0
+	for _, m := range matches {
+		start, end := m[2*s.group], m[2*s.group+1]
+		if start < 0 {
+			// The capture group did not participate in the match.
+			continue
+		}
+		buffer.Write(b[offset:start])
+		buffer.Write(value.Bytes())
+		offset = end
+	}
+	buffer.Write(b[offset:])
+	if bytes.Equal(b, buffer.Bytes()) {
+		return false, nil
+	}
+	return true, writeSyncedFile(fp, buffer.Bytes())
+}
@@ -0,0 +1,529 @@
+// Copyright 2020 Paul Greenberg (greenpau@outlook.com)
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package versioned
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testGoFile = `package main
+
+import (
+	"github.com/greenpau/versioned"
+)
+
+var app *versioned.PackageManager
+
+func init() {
+	app = versioned.NewPackageManager("myapp")
+	app.SetVersion(appVersion, "1.0.0")
+	app.SetGitBranch(gitBranch, "main")
+	app.SetGitCommit(gitCommit, "v1.0.0")
+}
+`
+
+func TestSyncers(t *testing.T) {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.0.1"
+	pkg.Git.Branch = "main"
+	pkg.Git.Commit = "v1.0.1"
+
+	for i, test := range []struct {
+		name       string
+		format     string
+		input      string
+		output     string
+		opts       *SyncOptions
+		shouldErr  bool
+		errMessage string
+	}{
+		{
+			name:   "requests.py",
+			input:  "import os\n\n__version__ = \"1.0.0\"\n",
+			output: "import os\n\n__version__ = '1.0.1'\n",
+		},
+		{
+			name:   "app-client",
+			format: "python",
+			input:  "#!/usr/bin/env python\n__version__ = '1.0.0'\n",
+			output: "#!/usr/bin/env python\n__version__ = '1.0.1'\n",
+		},
+		{
+			name:       "empty.py",
+			input:      "import os\n",
+			shouldErr:  true,
+			errMessage: "__version__ module level dunder not found",
+		},
+		{
+			name:   "__init__.py",
+			format: "blender",
+			input:  "bl_info = {\n    \"name\": \"Addon\",\n    \"version\": (1, 0, 0),\n    \"blender\": (2, 80, 0),\n}\n",
+			output: "bl_info = {\n    \"name\": \"Addon\",\n    \"version\": (1, 0, 1),\n    \"blender\": (2, 80, 0),\n}\n",
+		},
+		{
+			name:       "addon.py",
+			format:     "blender",
+			input:      "bl_info = {\n    \"name\": \"Addon\",\n}\n",
+			shouldErr:  true,
+			errMessage: "bl_info['version'] not found",
+		},
+		{
+			name:   "Config.ts",
+			input:  "export const Config = {\n  Version: \"1.0.0\",\n};\n",
+			output: "export const Config = {\n  Version: \"1.0.1\",\n};\n",
+		},
+		{
+			name:   "config.js",
+			input:  "module.exports = {\n  Version: '1.0.1',\n};\n",
+			output: "module.exports = {\n  Version: '1.0.1',\n};\n",
+		},
+		{
+			name:       "index.js",
+			input:      "module.exports = {};\n",
+			shouldErr:  true,
+			errMessage: "version not found",
+		},
+		{
+			name:   "package.json",
+			input:  "{\n  \"name\": \"app\",\n  \"version\": \"1.0.0\",\n  \"private\": true\n}\n",
+			output: "{\n  \"name\": \"app\",\n  \"version\": \"1.0.1\",\n  \"private\": true\n}\n",
+		},
+		{
+			name:       "package.json",
+			input:      "{\n  \"name\": \"app\"\n}\n",
+			shouldErr:  true,
+			errMessage: "version field not found",
+		},
+		{
+			name:   "main.go",
+			input:  testGoFile,
+			output: strings.NewReplacer(`"1.0.0"`, `"1.0.1"`, `"v1.0.0"`, `"v1.0.1"`).Replace(testGoFile),
+		},
+		{
+			name:   "main.go",
+			input:  testGoFile,
+			opts:   &SyncOptions{PreRelease: true},
+			output: strings.NewReplacer(`"1.0.0"`, `"1.0.1"`, `"main"`, `""`, `"v1.0.0"`, `"1.0.1"`).Replace(testGoFile),
+		},
+		{
+			name:       "main.go",
+			input:      "package main\n\nfunc main() {}\n",
+			shouldErr:  true,
+			errMessage: "package github.com/greenpau/versioned not found",
+		},
+	} {
+		fp := filepath.Join(t.TempDir(), test.name)
+		writeTestFile(t, fp, test.input)
+		changed, err := SyncFile(fp, pkg, test.format, test.opts)
+		if test.shouldErr {
+			if err == nil {
+				t.Fatalf("FAIL: test %d: expected error, but succeeded", i)
+			}
+			if !strings.Contains(err.Error(), test.errMessage) {
+				t.Fatalf("FAIL: test %d: error mismatch: %v (actual) vs. %s (expected)", i, err, test.errMessage)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if string(b) != test.output {
+			t.Fatalf("FAIL: test %d: output mismatch:\n%s (actual)\n%s (expected)", i, b, test.output)
+		}
+		if changed != (test.input != test.output) {
+			t.Fatalf("FAIL: test %d: changed: %t (actual) vs. %t (expected)", i, changed, !changed)
+		}
+		// The second run finds the file in sync.
+		if changed, err := SyncFile(fp, pkg, test.format, test.opts); err != nil || changed {
+			t.Fatalf("FAIL: test %d: second run: changed: %t, error: %v", i, changed, err)
+		}
+	}
+}
+
+func TestGetSyncer(t *testing.T) {
+	for i, test := range []struct {
+		format    string
+		fp        string
+		syncer    Syncer
+		shouldErr bool
+	}{
+		{fp: "setup.py", syncer: pythonSyncer{}},
+		{fp: "src/Config.ts", syncer: javascriptSyncer{}},
+		{fp: "web/package.json", syncer: packageJSONSyncer{}},
+		{fp: "web/my-package.json", syncer: packageJSONSyncer{}},
+		{fp: "mongo", shouldErr: true},
+		{fp: "cmd/app/main.go", syncer: golangSyncer{}},
+		{format: "blender", fp: "addon/__init__.py", syncer: blenderSyncer{}},
+		{format: "py", fp: "app-client", syncer: pythonSyncer{}},
+		{fp: "app-client", shouldErr: true},
+		{format: "cobol", fp: "main.go", shouldErr: true},
+	} {
+		s, err := GetSyncer(test.format, test.fp)
+		if test.shouldErr {
+			if err == nil {
+				t.Fatalf("FAIL: test %d: expected error, but succeeded", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if s != test.syncer {
+			t.Fatalf("FAIL: test %d: syncer mismatch: %T (actual) vs. %T (expected)", i, s, test.syncer)
+		}
+	}
+}
+
+type testSyncer struct{}
+
+func (testSyncer) Sync(fp string, pkg *PackageManager, opts *SyncOptions) (bool, error) {
+	return true, ioutil.WriteFile(fp, []byte(pkg.Version+"\n"), 0644)
+}
+
+func TestRegisterSyncer(t *testing.T) {
+	RegisterSyncer(testSyncer{}, ".version")
+	defer delete(syncers, ".version")
+	fp := filepath.Join(t.TempDir(), "app.version")
+	pkg := NewPackageManager("app")
+	pkg.Version = "2.0.0"
+	if _, err := SyncFile(fp, pkg, "", nil); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if b, _ := os.ReadFile(fp); string(b) != "2.0.0\n" {
+		t.Fatalf("FAIL: output mismatch: %q", b)
+	}
+}
+
+func TestRegexSyncer(t *testing.T) {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.2.3"
+	pkg.Git.Commit = "v1.2.3-4-gabcdef0"
+
+	for i, test := range []struct {
+		name      string
+		pattern   string
+		template  string
+		input     string
+		output    string
+		shouldErr bool
+	}{
+		{
+			name:    "Dockerfile",
+			pattern: `LABEL version="([^"]*)"`,
+			input:   "FROM alpine\nLABEL version=\"1.0.0\"\n",
+			output:  "FROM alpine\nLABEL version=\"1.2.3\"\n",
+		},
+		{
+			name:     "myapp.spec",
+			pattern:  `(?m)^Version:\s+(\S+)$`,
+			template: "{{.Major}}.{{.Minor}}",
+			input:    "Name: myapp\nVersion:  1.0\nRelease: 1\n",
+			output:   "Name: myapp\nVersion:  1.2\nRelease: 1\n",
+		},
+		{
+			name:    "deployment.yaml",
+			pattern: `image: (myapp):(?P<version>[\w.]+)`,
+			input:   "containers:\n  - image: myapp:1.0.0\n  - image: myapp:1.0.0\n",
+			output:  "containers:\n  - image: myapp:1.2.3\n  - image: myapp:1.2.3\n",
+		},
+		{
+			name:     "version.h",
+			pattern:  `#define MYAPP_VERSION_PATCH (\d+)`,
+			template: "{{.Patch}}",
+			input:    "#define MYAPP_VERSION_PATCH 3\n",
+			output:   "#define MYAPP_VERSION_PATCH 3\n",
+		},
+		{
+			name:     "version.h",
+			pattern:  `#define MYAPP_COMMIT "(.*)"`,
+			template: "{{.Commit}}",
+			input:    "#define MYAPP_COMMIT \"\"\n",
+			output:   "#define MYAPP_COMMIT \"v1.2.3-4-gabcdef0\"\n",
+		},
+		{
+			name:      "Dockerfile",
+			pattern:   `LABEL version="[^"]*"`,
+			shouldErr: true,
+		},
+		{
+			name:      "Dockerfile",
+			pattern:   `LABEL version="([^"]*)"`,
+			input:     "FROM alpine\n",
+			shouldErr: true,
+		},
+		{
+			name:      "Dockerfile",
+			pattern:   `LABEL version="([^"]*)"`,
+			template:  "{{.Major",
+			shouldErr: true,
+		},
+	} {
+		fp := filepath.Join(t.TempDir(), test.name)
+		writeTestFile(t, fp, test.input)
+		s, err := NewRegexSyncer(test.pattern, test.template)
+		if err == nil {
+			_, err = s.Sync(fp, pkg, nil)
+		}
+		if test.shouldErr {
+			if err == nil {
+				t.Fatalf("FAIL: test %d: expected error, but succeeded", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if string(b) != test.output {
+			t.Fatalf("FAIL: test %d: output mismatch:\n%s (actual)\n%s (expected)", i, b, test.output)
+		}
+	}
+}
+
+func TestSyncGoVar(t *testing.T) {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.2.3"
+
+	for i, test := range []struct {
+		name      string
+		input     string
+		output    string
+		shouldErr bool
+	}{
+		{
+			name:   "Version",
+			input:  "package main\n\n// Version is the version.\nconst Version = \"1.0.0\" // release\n",
+			output: "package main\n\n// Version is the version.\nconst Version = \"1.2.3\" // release\n",
+		},
+		{
+			name: "version",
+			input: "package app\n\nvar (\n\tname    = \"app\"\n\tversion = \"1.0.0\"  // not gofmt-ed\n" +
+				"\tcommit  string\n)\n",
+			output: "package app\n\nvar (\n\tname    = \"app\"\n\tversion = \"1.2.3\"  // not gofmt-ed\n" +
+				"\tcommit  string\n)\n",
+		},
+		{
+			name:   "Version",
+			input:  "package app\n\nvar Version string = `1.0.0`\n",
+			output: "package app\n\nvar Version string = `1.2.3`\n",
+		},
+		{
+			name:   "Version",
+			input:  "package app\n\nconst Major, Version = 1, \"1.2.3\"\n",
+			output: "package app\n\nconst Major, Version = 1, \"1.2.3\"\n",
+		},
+		{
+			name:      "Version",
+			input:     "package app\n\nfunc main() {\n\tversion := \"1.0.0\"\n\t_ = version\n}\n",
+			shouldErr: true,
+		},
+		{
+			name:      "Version",
+			input:     "package app\n\nvar Version string\n",
+			shouldErr: true,
+		},
+		{
+			name:      "Version",
+			input:     "package app\n\nvar Version = \n",
+			shouldErr: true,
+		},
+	} {
+		fp := filepath.Join(t.TempDir(), "version.go")
+		writeTestFile(t, fp, test.input)
+		changed, err := SyncFile(fp, pkg, "", &SyncOptions{GoVar: test.name})
+		if test.shouldErr {
+			if err == nil {
+				t.Fatalf("FAIL: test %d: expected error, but succeeded", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if string(b) != test.output {
+			t.Fatalf("FAIL: test %d: output mismatch:\n%s (actual)\n%s (expected)", i, b, test.output)
+		}
+		if changed != (test.input != test.output) {
+			t.Fatalf("FAIL: test %d: changed: %t (actual) vs. %t (expected)", i, changed, !changed)
+		}
+	}
+}
+
+func TestGolangSyncer(t *testing.T) {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.2.3"
+	pkg.Git.Branch = "main"
+	pkg.Git.Commit = "v1.2.3"
+
+	for i, test := range []struct {
+		input     string
+		output    string
+		shouldErr bool
+	}{
+		{
+			// Multiple init functions, the import alias, and multi-line calls.
+			input: "package main\n\nimport (\n\tv \"github.com/greenpau/versioned\"\n)\n\n" +
+				"var cli *v.PackageManager\n\nfunc init() {\n\tsetup()\n}\n\n" +
+				"func init() {\n\tcli = v.NewPackageManager(\"cli\")\n\tcli.SetVersion(\n\t\tappVersion,\n\t\t\"1.0.0\", // default\n\t)\n" +
+				"\tif true {\n\t\tcli.SetGitCommit(gitCommit, \"v1.0.0\")\n\t}\n}\n",
+			output: "package main\n\nimport (\n\tv \"github.com/greenpau/versioned\"\n)\n\n" +
+				"var cli *v.PackageManager\n\nfunc init() {\n\tsetup()\n}\n\n" +
+				"func init() {\n\tcli = v.NewPackageManager(\"cli\")\n\tcli.SetVersion(\n\t\tappVersion,\n\t\t\"1.2.3\", // default\n\t)\n" +
+				"\tif true {\n\t\tcli.SetGitCommit(gitCommit, \"v1.2.3\")\n\t}\n}\n",
+		},
+		{
+			// The package manager is a field, and another variable is
+			// skipped.
+			input: "package main\n\nimport \"github.com/greenpau/versioned\"\n\nfunc init() {\n" +
+				"\ts.app = versioned.NewPackageManager(\"app\")\n\ts.app.SetVersion(appVersion, \"1.0.0\")\n" +
+				"\tother.SetVersion(appVersion, \"0.0.1\")\n}\n",
+			output: "package main\n\nimport \"github.com/greenpau/versioned\"\n\nfunc init() {\n" +
+				"\ts.app = versioned.NewPackageManager(\"app\")\n\ts.app.SetVersion(appVersion, \"1.2.3\")\n" +
+				"\tother.SetVersion(appVersion, \"0.0.1\")\n}\n",
+		},
+		{
+			// The package manager is created at package level.
+			input: "package main\n\nimport \"github.com/greenpau/versioned\"\n\nvar app = versioned.NewPackageManager(\"app\")\n\n" +
+				"func init() {\n\tapp.SetVersion(appVersion, \"1.0.0\")\n\tapp.SetGitBranch(gitBranch, \"main\")\n}\n",
+			output: "package main\n\nimport \"github.com/greenpau/versioned\"\n\nvar app = versioned.NewPackageManager(\"app\")\n\n" +
+				"func init() {\n\tapp.SetVersion(appVersion, \"1.2.3\")\n\tapp.SetGitBranch(gitBranch, \"main\")\n}\n",
+		},
+		{
+			input:     "package main\n\nimport \"github.com/greenpau/versioned\"\n\nvar app *versioned.PackageManager\n",
+			shouldErr: true,
+		},
+		{
+			input: "package main\n\nimport \"github.com/greenpau/versioned\"\n\n" +
+				"func init() {\n\tapp := versioned.NewPackageManager(\"app\")\n\tapp.SetGitBranch(gitBranch, \"main\")\n}\n",
+			shouldErr: true,
+		},
+	} {
+		fp := filepath.Join(t.TempDir(), "main.go")
+		writeTestFile(t, fp, test.input)
+		if err := os.Chmod(fp, 0600); err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		_, err := SyncFile(fp, pkg, "", nil)
+		if test.shouldErr {
+			if err == nil {
+				t.Fatalf("FAIL: test %d: expected error, but succeeded", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if string(b) != test.output {
+			t.Fatalf("FAIL: test %d: output mismatch:\n%s (actual)\n%s (expected)", i, b, test.output)
+		}
+		fi, err := os.Stat(fp)
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if fi.Mode().Perm() != 0600 {
+			t.Fatalf("FAIL: test %d: file mode: %v (actual) vs. %v (expected)", i, fi.Mode().Perm(), os.FileMode(0600))
+		}
+	}
+}
+
+func TestGolangSyncerMetadata(t *testing.T) {
+	pkg := NewPackageManager("myapp")
+	pkg.Version = "1.2.3"
+	pkg.Description = "My \"app\""
+	pkg.Documentation = "https://example.com/myapp"
+	pkg.Build.User = "ci"
+	pkg.Build.Date = "2020-05-12"
+
+	for i, test := range []struct {
+		input     string
+		output    string
+		shouldErr bool
+	}{
+		{
+			// The existing description is updated, and the missing
+			// documentation is added.
+			input: "package main\n\nimport \"github.com/greenpau/versioned\"\n\nfunc init() {\n" +
+				"\tapp = versioned.NewPackageManager(\"myapp\")\n\tapp.Description = `old`\n" +
+				"\tapp.SetVersion(appVersion, \"1.0.0\")\n\tapp.SetBuildUser(buildUser, \"\")\n" +
+				"\tapp.SetBuildDate(buildDate, \"2020-01-01\")\n}\n",
+			output: "package main\n\nimport \"github.com/greenpau/versioned\"\n\nfunc init() {\n" +
+				"\tapp = versioned.NewPackageManager(\"myapp\")\n\tapp.Documentation = \"https://example.com/myapp\"\n" +
+				"\tapp.Description = `My \"app\"`\n" +
+				"\tapp.SetVersion(appVersion, \"1.2.3\")\n\tapp.SetBuildUser(buildUser, \"ci\")\n" +
+				"\tapp.SetBuildDate(buildDate, \"2020-05-12\")\n}\n",
+		},
+		{
+			// The build user call is missing.
+			input: "package main\n\nimport \"github.com/greenpau/versioned\"\n\nfunc init() {\n" +
+				"\tapp = versioned.NewPackageManager(\"myapp\")\n\tapp.SetVersion(appVersion, \"1.0.0\")\n" +
+				"\tapp.SetBuildDate(buildDate, \"2020-01-01\")\n}\n",
+			shouldErr: true,
+		},
+	} {
+		fp := filepath.Join(t.TempDir(), "main.go")
+		writeTestFile(t, fp, test.input)
+		_, err := SyncFile(fp, pkg, "", nil)
+		if test.shouldErr {
+			if err == nil {
+				t.Fatalf("FAIL: test %d: expected error, but succeeded", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			t.Fatalf("FAIL: test %d: %v", i, err)
+		}
+		if string(b) != test.output {
+			t.Fatalf("FAIL: test %d: output mismatch:\n%s (actual)\n%s (expected)", i, b, test.output)
+		}
+	}
+}
+
+func TestSourceDate(t *testing.T) {
+	t.Setenv("SOURCE_DATE_EPOCH", "1589277600")
+	d, err := SourceDate(".")
+	if err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	if s := d.Format("2006-01-02T15:04:05Z07:00"); s != "2020-05-12T10:00:00Z" {
+		t.Fatalf("FAIL: source date: %s (actual) vs. 2020-05-12T10:00:00Z (expected)", s)
+	}
+	t.Setenv("SOURCE_DATE_EPOCH", "yesterday")
+	if _, err := SourceDate("."); err == nil {
+		t.Fatalf("FAIL: expected error, but succeeded")
+	}
+}
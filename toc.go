@@ -20,24 +20,76 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"regexp"
 	"strings"
 )
 
-const allowedLinkChars = "0123456789abcdefghijklmnopqrstuvwxyz-"
+// tocIgnoreMarker excludes a heading, and its subsections, from the table
+// of contents. The marker is either at the end of the heading line or on
+// the line preceding the heading.
+const tocIgnoreMarker = "<!-- toc-ignore -->"
+
+// knownTocMarkers are the begin and end markers of the tables of contents
+// generated by other tools. The tables of contents between the markers
+// get updated and the markers get replaced with the configured ones.
+var knownTocMarkers = [][]string{
+	// versioned defaults
+	{"<!-- begin-markdown-toc -->", "<!-- end-markdown-toc -->"},
+	// doctoc
+	{"<!-- START doctoc", "<!-- END doctoc"},
+	// markdown-toc
+	{"<!-- toc -->", "<!-- tocstop -->"},
+	// Markdown All in One
+	{"<!-- TOC -->", "<!-- /TOC -->"},
+}
 
 // TableOfContents represent Markdown Table of Contents section.
 type TableOfContents struct {
-	FilePath  string
+	FilePath    string
+	BeginMarker string
+	EndMarker   string
+	Title       string
+	TitleLevel  int
+	NoTitle     bool
+	// MinLevel and MaxLevel are the levels of the headings included in
+	// the table of contents.
+	MinLevel        int
+	MaxLevel        int
+	ExcludePatterns []*regexp.Regexp
+	// SlugStyle is the platform whose heading anchors the links follow,
+	// i.e. github, gitlab, bitbucket, or hugo.
+	SlugStyle string
+	// ListStyle is the style of the list of the table of contents, i.e.
+	// "*", "-", or "+" bullets, "ordered" list, or "numbered" sections.
+	ListStyle string
+	// NumberHeadings enables writing section numbers, e.g. "1.2.", to
+	// the headings included in the table of contents.
+	NumberHeadings bool
+	// Lenient enables the normalization of the headings skipping levels,
+	// e.g. "####" following "##". The skipped levels are reported in
+	// Warnings instead of failing the update.
+	Lenient  bool
+	Warnings []string
+	// Format is the format of the document, i.e. markdown, rst, or
+	// asciidoc. When empty, it is determined by the file extension.
+	Format    string
+	format    documentFormat
+	slugger   Slugger
 	entries   []*tocEntry
 	maxDepth  int
 	minDepth  int
 	lastDepth int
-	sep       string
-	linkRef   map[string]int
+	// depths is the stack of the levels of the ancestors of the last
+	// heading and their depths in the table of contents.
+	depths [][2]int
+	sep    string
 }
 
 type tocEntry struct {
+	// title is the text of the entry, and name is the text of the
+	// heading. They differ when the entry is prefixed with section number.
 	title string
+	name  string
 	link  string
 	depth int
 }
@@ -45,15 +97,44 @@ type tocEntry struct {
 // NewTableOfContents return a new instance of TableOfContents.
 func NewTableOfContents() *TableOfContents {
 	return &TableOfContents{
-		FilePath: "README.md",
-		entries:  []*tocEntry{},
-		minDepth: 1000,
-		maxDepth: 0,
-		sep:      "*",
-		linkRef:  make(map[string]int),
+		FilePath:    "README.md",
+		BeginMarker: "<!-- begin-markdown-toc -->",
+		EndMarker:   "<!-- end-markdown-toc -->",
+		Title:       "Table of Contents",
+		TitleLevel:  2,
+		MinLevel:    2,
+		MaxLevel:    6,
+		entries:     []*tocEntry{},
+		minDepth:    1000,
+		maxDepth:    0,
+		sep:         "*",
+		ListStyle:   "*",
+		SlugStyle:   "github",
 	}
 }
 
+// clone returns a new instance of TableOfContents for the provided file
+// with the configuration of the table of contents.
+func (toc *TableOfContents) clone(fp string) *TableOfContents {
+	c := NewTableOfContents()
+	c.FilePath = fp
+	c.BeginMarker = toc.BeginMarker
+	c.EndMarker = toc.EndMarker
+	c.Title = toc.Title
+	c.TitleLevel = toc.TitleLevel
+	c.NoTitle = toc.NoTitle
+	c.MinLevel = toc.MinLevel
+	c.MaxLevel = toc.MaxLevel
+	c.ExcludePatterns = toc.ExcludePatterns
+	c.SlugStyle = toc.SlugStyle
+	c.Format = toc.Format
+	c.sep = toc.sep
+	c.ListStyle = toc.ListStyle
+	c.NumberHeadings = toc.NumberHeadings
+	c.Lenient = toc.Lenient
+	return c
+}
+
 // AddFilePath adds markdown file path.
 func (toc *TableOfContents) AddFilePath(s string) {
 	if s == "" {
@@ -62,6 +143,140 @@ func (toc *TableOfContents) AddFilePath(s string) {
 	toc.FilePath = s
 }
 
+// AddMarkers adds the markers surrounding the table of contents.
+func (toc *TableOfContents) AddMarkers(begin, end string) error {
+	begin = strings.TrimSpace(begin)
+	end = strings.TrimSpace(end)
+	if begin == "" || end == "" {
+		return fmt.Errorf("toc marker is empty")
+	}
+	if begin == end {
+		return fmt.Errorf("toc begin and end markers must be different")
+	}
+	toc.BeginMarker = begin
+	toc.EndMarker = end
+	return nil
+}
+
+// AddTitle adds the title of the table of contents.
+func (toc *TableOfContents) AddTitle(s string) {
+	if s == "" {
+		return
+	}
+	toc.Title = s
+}
+
+// AddTitleLevel adds the heading level of the title of the table of contents.
+func (toc *TableOfContents) AddTitleLevel(i int) error {
+	if i < 1 || i > 6 {
+		return fmt.Errorf("toc title level must be between 1 and 6, got %d", i)
+	}
+	toc.TitleLevel = i
+	return nil
+}
+
+// AddListStyle adds the style of the list of the table of contents, i.e.
+// "*", "-", or "+" bullets, "ordered" list, e.g. "1.", or "numbered" list
+// with hierarchical section numbers, e.g. "1.2.".
+func (toc *TableOfContents) AddListStyle(s string) error {
+	switch s {
+	case "*", "-", "+":
+		toc.sep = s
+	case "ordered", "numbered":
+		toc.sep = "*"
+	default:
+		return fmt.Errorf("toc list style %q is unsupported", s)
+	}
+	toc.ListStyle = s
+	return nil
+}
+
+// EnableHeadingNumbers enables writing section numbers, e.g. "1.2.", to
+// the headings included in the table of contents. The existing numbers
+// get replaced.
+func (toc *TableOfContents) EnableHeadingNumbers() {
+	toc.NumberHeadings = true
+}
+
+// EnableLenientMode enables the normalization of the headings skipping
+// levels. The skipped levels are reported as warnings.
+func (toc *TableOfContents) EnableLenientMode() {
+	toc.Lenient = true
+}
+
+// DisableTitle removes the title from the table of contents.
+func (toc *TableOfContents) DisableTitle() {
+	toc.NoTitle = true
+}
+
+// AddLevels adds the minimum and maximum levels of the headings
+// included in the table of contents.
+func (toc *TableOfContents) AddLevels(min, max int) error {
+	if min < 1 || max > 6 || min > max {
+		return fmt.Errorf("toc levels must be between 1 and 6, got %d to %d", min, max)
+	}
+	toc.MinLevel = min
+	toc.MaxLevel = max
+	return nil
+}
+
+// AddExcludePattern adds a regular expression. The headings matching
+// the expression, and their subsections, are excluded from the table
+// of contents.
+func (toc *TableOfContents) AddExcludePattern(s string) error {
+	if s == "" {
+		return fmt.Errorf("toc exclude pattern is empty")
+	}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		return fmt.Errorf("failed compiling toc exclude pattern %q: %v", s, err)
+	}
+	toc.ExcludePatterns = append(toc.ExcludePatterns, re)
+	return nil
+}
+
+// AddSlugStyle adds the platform whose heading anchors the links of the
+// table of contents follow, i.e. github, gitlab, bitbucket, or hugo.
+func (toc *TableOfContents) AddSlugStyle(s string) error {
+	slugger, err := NewSlugger(s)
+	if err != nil {
+		return err
+	}
+	toc.SlugStyle = s
+	toc.slugger = slugger
+	return nil
+}
+
+// AddFormat adds the format of the document, i.e. markdown, rst, or
+// asciidoc.
+func (toc *TableOfContents) AddFormat(s string) error {
+	f, err := getDocumentFormat(s, toc.FilePath)
+	if err != nil {
+		return err
+	}
+	toc.Format = s
+	toc.format = f
+	return nil
+}
+
+// getFormat returns the format of the document.
+func (toc *TableOfContents) getFormat() (documentFormat, error) {
+	if toc.format != nil {
+		return toc.format, nil
+	}
+	return getDocumentFormat(toc.Format, toc.FilePath)
+}
+
+// isExcluded returns true when the heading matches exclude patterns.
+func (toc *TableOfContents) isExcluded(title string) bool {
+	for _, re := range toc.ExcludePatterns {
+		if re.MatchString(title) {
+			return true
+		}
+	}
+	return false
+}
+
 // AddHeading adds an entry to TableOfContents.
 func (toc *TableOfContents) AddHeading(s string) error {
 	if s == "" {
@@ -71,9 +286,50 @@ func (toc *TableOfContents) AddHeading(s string) error {
 		return fmt.Errorf("heading must start with a pound")
 	}
 	arr := strings.SplitN(s, " ", 2)
+	title := strings.TrimSpace(arr[1])
+	depth, err := toc.getDepth(len(arr[0]))
+	if err != nil {
+		return err
+	}
+	toc.addEntry(depth, title, title, "")
+	return nil
+}
+
+// getDepth returns the depth of a heading in the table of contents. In
+// lenient mode, the depth of the heading skipping levels is one more than
+// the depth of its parent.
+func (toc *TableOfContents) getDepth(level int) (int, error) {
+	depthDiff := level - toc.lastDepth
+	if (depthDiff) > 1 && toc.lastDepth > 0 {
+		err := fmt.Errorf(
+			"heading hopped more than one level: %d, %d (current) vs. %d (previous)",
+			depthDiff, level, toc.lastDepth,
+		)
+		if !toc.Lenient {
+			return 0, err
+		}
+		toc.Warnings = append(toc.Warnings, err.Error())
+	}
+	toc.lastDepth = level
+	for len(toc.depths) > 0 && toc.depths[len(toc.depths)-1][0] >= level {
+		toc.depths = toc.depths[:len(toc.depths)-1]
+	}
+	depth := level
+	if len(toc.depths) > 0 {
+		depth = toc.depths[len(toc.depths)-1][1] + 1
+	}
+	toc.depths = append(toc.depths, [2]int{level, depth})
+	return depth, nil
+}
+
+// addEntry adds an entry to TableOfContents. When the link is empty,
+// it is generated from the heading.
+func (toc *TableOfContents) addEntry(depth int, name, title, link string) {
 	h := &tocEntry{
-		depth: len(arr[0]),
-		title: strings.TrimSpace(arr[1]),
+		depth: depth,
+		title: title,
+		name:  name,
+		link:  link,
 	}
 	if h.depth > toc.maxDepth {
 		toc.maxDepth = h.depth
@@ -81,56 +337,82 @@ func (toc *TableOfContents) AddHeading(s string) error {
 	if h.depth < toc.minDepth {
 		toc.minDepth = h.depth
 	}
-	depthDiff := h.depth - toc.lastDepth
-	if (depthDiff) > 1 && toc.lastDepth > 0 {
-		return fmt.Errorf(
-			"heading hopped more than one level: %d, %d (current) vs. %d (previous)",
-			depthDiff, h.depth, toc.lastDepth,
-		)
+	if h.link == "" {
+		h.link = toc.getLink(h.name)
 	}
-	toc.lastDepth = h.depth
 	toc.entries = append(toc.entries, h)
-	return nil
 }
 
+// getLink returns the link to the anchor of a heading. The duplicate
+// headings get numbered anchors.
 func (toc *TableOfContents) getLink(s string) string {
-	s = strings.ToLower(s)
-	link := "#"
-	for _, c := range s {
-		if string(c) == " " {
-			link += "-"
-			continue
-		}
-		if !strings.Contains(allowedLinkChars, string(c)) {
-			continue
+	if toc.slugger == nil {
+		toc.slugger, _ = NewSlugger(toc.SlugStyle)
+		if toc.slugger == nil {
+			toc.slugger, _ = NewSlugger("github")
 		}
-		link += string(c)
-	}
-	i, exists := toc.linkRef[link]
-	if exists {
-		toc.linkRef[link]++
-		link = fmt.Sprintf("%s-%d", link, i)
-	} else {
-		toc.linkRef[link] = 1
 	}
-
-	return link
+	return "#" + toc.slugger.Slug(getPlainText(s))
 }
 
 // ToString return string representation of TableOfContents.
 func (toc *TableOfContents) ToString() string {
-	var tocBuffer bytes.Buffer
-	for _, h := range toc.entries {
-		offsetDepth := h.depth - toc.minDepth
-		tocBuffer.WriteString(strings.Repeat("  ", offsetDepth))
-		tocBuffer.WriteString(fmt.Sprintf("%s [%s](%s)", toc.sep, h.title, toc.getLink(h.title)))
-		tocBuffer.WriteString("\n")
+	f, err := toc.getFormat()
+	if err != nil {
+		f = markdownFormat{}
 	}
-	return tocBuffer.String()
+	return strings.Join(f.renderEntries(toc.entries, toc.minDepth, toc.sep, toc.ListStyle == "ordered"), "\n")
+}
+
+// getEndMarker returns the end marker matching the begin marker
+// found in the provided line.
+func (toc *TableOfContents) getEndMarker(line string) (string, bool) {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, toc.BeginMarker) {
+		return toc.EndMarker, true
+	}
+	f, err := toc.getFormat()
+	if err != nil {
+		return "", false
+	}
+	for _, markers := range f.markers() {
+		if strings.HasPrefix(line, markers[0]) {
+			return markers[1], true
+		}
+	}
+	return "", false
+}
+
+// render returns the lines of the table of contents, including markers.
+func (toc *TableOfContents) render() []string {
+	f, err := toc.getFormat()
+	if err != nil {
+		f = markdownFormat{}
+	}
+	lines := []string{toc.BeginMarker}
+	if !toc.NoTitle {
+		lines = append(lines, f.renderTitle(toc.Title, toc.TitleLevel)...)
+	}
+	lines = append(lines, strings.Split(toc.ToString(), "\n")...)
+	lines = append(lines, toc.EndMarker)
+	return lines
 }
 
 // UpdateToc updates table of contents of the provided file.
 func UpdateToc(toc *TableOfContents) error {
+	f, err := toc.getFormat()
+	if err != nil {
+		return err
+	}
+	toc.format = f
+	if toc.BeginMarker == knownTocMarkers[0][0] && toc.EndMarker == knownTocMarkers[0][1] {
+		// The default markers are specific to the format.
+		toc.BeginMarker, toc.EndMarker = f.markers()[0][0], f.markers()[0][1]
+	}
+	if slugger := f.slugger(); slugger != nil {
+		toc.slugger = slugger
+	}
+
 	fi, err := os.Stat(toc.FilePath)
 	if err != nil {
 		return err
@@ -139,95 +421,169 @@ func UpdateToc(toc *TableOfContents) error {
 		return fmt.Errorf("path %q is not a file", toc.FilePath)
 	}
 
-	var fileBuffer bytes.Buffer
-	var fileLines []string
-	var tocBuffer bytes.Buffer
-	var tocBeginMarker = "<!-- begin-markdown-toc -->"
-	var tocEndMarker = "<!-- end-markdown-toc -->"
-	var isTocOutdated = true
-	var isTocFound bool
-	var isInsideToc bool
-	var tocIndex int
-	var firstHeadingIndex int
-
-	fh, err := os.Open(toc.FilePath)
+	b, err := ioutil.ReadFile(toc.FilePath)
 	if err != nil {
 		return err
 	}
-	defer fh.Close()
 
-	// Discovery Scan
-	var i int
-	scanner := bufio.NewScanner(fh)
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(b))
 	for scanner.Scan() {
-		line := scanner.Text()
-		if !isTocFound && firstHeadingIndex == 0 {
-			if strings.HasPrefix(line, "##") {
-				firstHeadingIndex = i
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	// Discovery Scan
+	doc := f.parse(lines)
+	var endMarker string
+	tocBeginIndex, tocEndIndex := -1, -1
+	for i, line := range lines {
+		if doc.literal[i] {
+			continue
+		}
+		if tocBeginIndex < 0 {
+			if marker, found := toc.getEndMarker(line); found {
+				tocBeginIndex = i
+				endMarker = marker
 			}
+			continue
 		}
+		if strings.HasPrefix(strings.TrimSpace(line), endMarker) {
+			tocEndIndex = i
+			break
+		}
+	}
 
-		if strings.HasPrefix(line, tocEndMarker) {
-			isInsideToc = false
+	// The headings of the table of contents are excluded.
+	var headings []*parsedHeading
+	for _, h := range doc.headings {
+		if tocBeginIndex >= 0 && h.line >= tocBeginIndex && (tocEndIndex < 0 || h.line <= tocEndIndex) {
 			continue
 		}
-		if strings.HasPrefix(line, tocBeginMarker) {
-			isInsideToc = true
-			isTocFound = true
-			tocIndex = i
-			firstHeadingIndex = 0
+		if h.title == "" {
 			continue
 		}
+		headings = append(headings, h)
+	}
 
-		if isInsideToc {
-			tocBuffer.WriteString(line + "\n")
-			continue
+	firstHeadingIndex := -1
+	for _, h := range headings {
+		if h.level >= toc.MinLevel {
+			firstHeadingIndex = h.line
+			break
 		}
+	}
+	tocIndex := tocBeginIndex
+	if tocIndex < 0 {
+		tocIndex = firstHeadingIndex
+	}
 
-		if !isInsideToc {
-			if strings.HasPrefix(line, "##") {
-				if firstHeadingIndex == 0 {
-					firstHeadingIndex = i
-				}
-				if err := toc.AddHeading(line); err != nil {
-					return fmt.Errorf("toc error: %s", err.Error())
+	// The exclusion of the headings is determined first, because the
+	// section numbers depend on the level of the top included headings.
+	ignoreMarker := f.ignoreMarker()
+	titles := make([]string, len(headings))
+	included := make([]bool, len(headings))
+	var excludeLevel, topLevel int
+	for i, h := range headings {
+		title := h.title
+		isIgnored := strings.HasSuffix(title, ignoreMarker) || (h.line > 0 && strings.TrimSpace(lines[h.line-1]) == ignoreMarker)
+		if f.ignoreMarkerSkipsBlank() {
+			for j := h.line - 1; j >= 0; j-- {
+				// The marker is on the preceding non-blank line.
+				if s := strings.TrimSpace(lines[j]); s != "" {
+					isIgnored = isIgnored || s == ignoreMarker
+					break
 				}
 			}
 		}
-
-		fileLines = append(fileLines, line)
-		i++
+		title = strings.TrimSpace(strings.TrimSuffix(title, ignoreMarker))
+		titles[i] = title
+		if excludeLevel == 0 || h.level <= excludeLevel {
+			excludeLevel = 0
+			if isIgnored || toc.isExcluded(title) {
+				excludeLevel = h.level
+			}
+		}
+		if excludeLevel > 0 || h.level < toc.MinLevel || h.level > toc.MaxLevel {
+			continue
+		}
+		included[i] = true
+		if topLevel == 0 || h.level < topLevel {
+			topLevel = h.level
+		}
 	}
-	if err := scanner.Err(); err != nil {
-		return err
+
+	isNumbered := toc.NumberHeadings || toc.ListStyle == "numbered"
+	isTitleReserved := toc.NoTitle || tocIndex < 0 || !f.hasTitleAnchor()
+	var counters []int
+	for i, h := range headings {
+		if !isTitleReserved && h.line >= tocIndex {
+			// The title of the table of contents precedes the heading.
+			toc.getLink(toc.Title)
+			isTitleReserved = true
+		}
+		title := titles[i]
+		if !included[i] {
+			// Reserve the link, because the duplicate headings are numbered.
+			if h.id == "" {
+				toc.getLink(title)
+			}
+			continue
+		}
+		warnings := len(toc.Warnings)
+		depth, err := toc.getDepth(h.level)
+		if err != nil {
+			return fmt.Errorf("toc error: %s", err.Error())
+		}
+		if len(toc.Warnings) > warnings {
+			toc.Warnings[warnings] = fmt.Sprintf("%s:%d: %s", toc.FilePath, h.text+1, toc.Warnings[warnings])
+		}
+		text := title
+		if isNumbered {
+			counters = getSectionCounters(counters, depth-topLevel)
+			number := getSectionNumber(counters)
+			if toc.NumberHeadings {
+				title = number + " " + stripSectionNumber(title)
+				text = title
+				f.setHeadingNumber(lines, h, number)
+			} else {
+				text = number + " " + title
+			}
+		}
+		var link string
+		if h.id != "" {
+			link = "#" + h.id
+		}
+		toc.addEntry(depth, title, text, link)
 	}
 
-	if isTocFound && isInsideToc {
+	if tocBeginIndex >= 0 && tocEndIndex < 0 {
 		return fmt.Errorf("toc error: failed to find end marker")
 	}
 
-	if !isTocOutdated {
+	var output []string
+	switch {
+	case tocBeginIndex >= 0:
+		output = append(output, lines[:tocBeginIndex]...)
+		output = append(output, toc.render()...)
+		output = append(output, lines[tocEndIndex+1:]...)
+	case firstHeadingIndex >= 0:
+		output = append(output, lines[:firstHeadingIndex]...)
+		output = append(output, toc.render()...)
+		output = append(output, "")
+		output = append(output, lines[firstHeadingIndex:]...)
+	default:
+		// The file has no headings.
 		return nil
 	}
 
-	// Found outdated Table of Contents
-	if isTocFound {
-		fileBuffer.WriteString(strings.Join(fileLines[:tocIndex+1], "\n"))
-	} else {
-		fileBuffer.WriteString(strings.Join(fileLines[:firstHeadingIndex], "\n"))
-		fileBuffer.WriteString("\n")
-	}
-	fileBuffer.WriteString(tocBeginMarker + "\n")
-	fileBuffer.WriteString("## Table of Contents" + "\n\n")
-	fileBuffer.WriteString(toc.ToString() + "\n")
-	fileBuffer.WriteString(tocEndMarker + "\n")
-	if isTocFound {
-		fileBuffer.WriteString(strings.Join(fileLines[tocIndex:], "\n") + "\n")
-	} else {
-		fileBuffer.WriteString("\n")
-		fileBuffer.WriteString(strings.Join(fileLines[firstHeadingIndex:], "\n") + "\n")
+	fileBuffer := []byte(strings.Join(output, "\n") + "\n")
+	if bytes.Equal(fileBuffer, b) {
+		return nil
 	}
 	mode := fi.Mode()
 
-	return ioutil.WriteFile(toc.FilePath, fileBuffer.Bytes(), mode.Perm())
+	return ioutil.WriteFile(toc.FilePath, fileBuffer, mode.Perm())
 }
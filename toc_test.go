@@ -15,6 +15,8 @@
 package versioned
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 )
 
@@ -81,3 +83,181 @@ func TestNewTableOfContents(t *testing.T) {
 
 	t.Logf("\n\n%s\n", toc.ToString())
 }
+
+func TestUpdateTocMarkers(t *testing.T) {
+	for i, test := range []struct {
+		input  string
+		output string
+		setup  func(*TableOfContents) error
+	}{
+		{
+			input: "# Title\n\n## Foo\n\n### Bar\n",
+			output: "# Title\n\n<!-- begin-markdown-toc -->\n## Table of Contents\n\n" +
+				"* [Foo](#foo)\n  * [Bar](#bar)\n\n<!-- end-markdown-toc -->\n\n## Foo\n\n### Bar\n",
+		},
+		{
+			input: "# Title\n\n<!-- START doctoc generated TOC please keep comment here to allow auto update -->\n" +
+				"- [Old](#old)\n<!-- END doctoc generated TOC please keep comment here to allow auto update -->\n\n## Foo\n",
+			output: "# Title\n\n<!-- begin-markdown-toc -->\n### Contents\n\n" +
+				"* [Foo](#foo)\n\n<!-- end-markdown-toc -->\n\n## Foo\n",
+			setup: func(toc *TableOfContents) error {
+				toc.AddTitle("Contents")
+				return toc.AddTitleLevel(3)
+			},
+		},
+		{
+			input:  "# Title\n\n<!-- begin-markdown-toc -->\n<!-- end-markdown-toc -->\n\n## Foo\n",
+			output: "# Title\n\n<!-- toc -->\n* [Foo](#foo)\n\n<!-- tocstop -->\n\n## Foo\n",
+			setup: func(toc *TableOfContents) error {
+				toc.DisableTitle()
+				return toc.AddMarkers("<!-- toc -->", "<!-- tocstop -->")
+			},
+		},
+		{
+			// The Markdown ignore marker is on the line right before the
+			// heading, not separated by a blank line.
+			input: "# Title\n\n## Foo\n\n### Bar\n\n#### Baz\n\n<!-- toc-ignore -->\n## Example\n\n### Qux\n\n" +
+				"## API <!-- toc-ignore -->\n\n### Example\n\n## Internal\n\n<!-- toc-ignore -->\n\n## Example\n",
+			output: "# Title\n\n<!-- begin-markdown-toc -->\n## Table of Contents\n\n" +
+				"* [Foo](#foo)\n  * [Bar](#bar)\n* [Example](#example-2)\n\n<!-- end-markdown-toc -->\n\n" +
+				"## Foo\n\n### Bar\n\n#### Baz\n\n<!-- toc-ignore -->\n## Example\n\n### Qux\n\n" +
+				"## API <!-- toc-ignore -->\n\n### Example\n\n## Internal\n\n<!-- toc-ignore -->\n\n## Example\n",
+			setup: func(toc *TableOfContents) error {
+				if err := toc.AddExcludePattern("^Int"); err != nil {
+					return err
+				}
+				return toc.AddLevels(2, 3)
+			},
+		},
+		{
+			input: "# Title\n\n```bash\n# comment\n## not a heading\n<!-- begin-markdown-toc -->\n```\n\nFoo\n---\n",
+			output: "# Title\n\n```bash\n# comment\n## not a heading\n<!-- begin-markdown-toc -->\n```\n\n" +
+				"<!-- begin-markdown-toc -->\n## Table of Contents\n\n* [Foo](#foo)\n\n<!-- end-markdown-toc -->\n\nFoo\n---\n",
+		},
+		{
+			input:  "# Title\n\n## Foo\n\n### Bar\n",
+			output: "# Title\n\n<!-- begin-markdown-toc -->\n- [Foo](#foo)\n  - [Bar](#bar)\n\n<!-- end-markdown-toc -->\n\n## Foo\n\n### Bar\n",
+			setup: func(toc *TableOfContents) error {
+				toc.DisableTitle()
+				return toc.AddListStyle("-")
+			},
+		},
+		{
+			input: "## Foo\n\n### Bar\n\n### Baz\n\n## Qux\n",
+			output: "<!-- begin-markdown-toc -->\n1. [Foo](#foo)\n    1. [Bar](#bar)\n    2. [Baz](#baz)\n2. [Qux](#qux)\n\n" +
+				"<!-- end-markdown-toc -->\n\n## Foo\n\n### Bar\n\n### Baz\n\n## Qux\n",
+			setup: func(toc *TableOfContents) error {
+				toc.DisableTitle()
+				return toc.AddListStyle("ordered")
+			},
+		},
+		{
+			input: "## Foo\n\n### Bar\n\n## Qux\n",
+			output: "<!-- begin-markdown-toc -->\n* [1. Foo](#foo)\n  * [1.1. Bar](#bar)\n* [2. Qux](#qux)\n\n" +
+				"<!-- end-markdown-toc -->\n\n## Foo\n\n### Bar\n\n## Qux\n",
+			setup: func(toc *TableOfContents) error {
+				toc.DisableTitle()
+				return toc.AddListStyle("numbered")
+			},
+		},
+		{
+			input: "# Title\n\n## 3. Foo\n\n### Bar ###\n\nQux\n---\n\n## Appendix <!-- toc-ignore -->\n",
+			output: "# Title\n\n<!-- begin-markdown-toc -->\n* [1. Foo](#1-foo)\n  * [1.1. Bar](#11-bar)\n* [2. Qux](#2-qux)\n\n" +
+				"<!-- end-markdown-toc -->\n\n## 1. Foo\n\n### 1.1. Bar ###\n\n2\\. Qux\n---\n\n## Appendix <!-- toc-ignore -->\n",
+			setup: func(toc *TableOfContents) error {
+				toc.DisableTitle()
+				toc.EnableHeadingNumbers()
+				return nil
+			},
+		},
+	} {
+		fp := filepath.Join(t.TempDir(), "README.md")
+		if err := ioutil.WriteFile(fp, []byte(test.input), 0644); err != nil {
+			t.Fatal(err)
+		}
+		toc := NewTableOfContents()
+		toc.AddFilePath(fp)
+		if test.setup != nil {
+			if err := test.setup(toc); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := UpdateToc(toc); err != nil {
+			t.Fatalf("FAIL: Test %d: %v", i, err)
+		}
+		b, err := ioutil.ReadFile(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != test.output {
+			t.Fatalf("FAIL: Test %d: output mismatch:\n>>>got:\n%s\n>>>expected:\n%s", i, b, test.output)
+		}
+
+		// The update is idempotent.
+		toc = NewTableOfContents()
+		toc.AddFilePath(fp)
+		if test.setup != nil {
+			if err := test.setup(toc); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := UpdateToc(toc); err != nil {
+			t.Fatalf("FAIL: Test %d: %v", i, err)
+		}
+		if b, _ := ioutil.ReadFile(fp); string(b) != test.output {
+			t.Fatalf("FAIL: Test %d: second update changed the output:\n%s", i, b)
+		}
+	}
+
+	toc := NewTableOfContents()
+	if err := toc.AddLevels(3, 2); err == nil {
+		t.Fatal("FAIL: expected toc levels error, got success")
+	}
+	if err := toc.AddExcludePattern("("); err == nil {
+		t.Fatal("FAIL: expected toc exclude pattern error, got success")
+	}
+	if err := toc.AddMarkers("<!-- toc -->", ""); err == nil {
+		t.Fatal("FAIL: expected empty marker error, got success")
+	}
+	if err := toc.AddListStyle("#"); err == nil {
+		t.Fatal("FAIL: expected list style error, got success")
+	}
+	if err := toc.AddTitleLevel(7); err == nil {
+		t.Fatal("FAIL: expected title level error, got success")
+	}
+}
+
+func TestUpdateTocLenient(t *testing.T) {
+	input := "# Title\n\n## Foo\n\n#### Bar\n\n#### Baz\n\n##### Qux\n\n## Quux\n"
+	output := "# Title\n\n<!-- begin-markdown-toc -->\n* [Foo](#foo)\n  * [Bar](#bar)\n  * [Baz](#baz)\n    * [Qux](#qux)\n* [Quux](#quux)\n\n" +
+		"<!-- end-markdown-toc -->\n\n## Foo\n\n#### Bar\n\n#### Baz\n\n##### Qux\n\n## Quux\n"
+	fp := filepath.Join(t.TempDir(), "README.md")
+	if err := ioutil.WriteFile(fp, []byte(input), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	toc := NewTableOfContents()
+	toc.AddFilePath(fp)
+	if err := UpdateToc(toc); err == nil {
+		t.Fatal("FAIL: expected heading hop error, got success")
+	}
+
+	toc = NewTableOfContents()
+	toc.AddFilePath(fp)
+	toc.DisableTitle()
+	toc.EnableLenientMode()
+	if err := UpdateToc(toc); err != nil {
+		t.Fatalf("FAIL: %v", err)
+	}
+	b, err := ioutil.ReadFile(fp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != output {
+		t.Fatalf("FAIL: output mismatch:\n>>>got:\n%s\n>>>expected:\n%s", b, output)
+	}
+	expected := fp + ":5: heading hopped more than one level: 2, 4 (current) vs. 2 (previous)"
+	if len(toc.Warnings) != 1 || toc.Warnings[0] != expected {
+		t.Fatalf("FAIL: warnings mismatch: %v (actual) vs. %v (expected)", toc.Warnings, expected)
+	}
+}
@@ -30,12 +30,19 @@ import (
 // PackageManager stores metadata about a package.
 type PackageManager struct {
 	Name          string        `json:"name" xml:"name" yaml:"name"`
+	Module        string        `json:"module" xml:"module" yaml:"module"`
 	Version       string        `json:"version" xml:"version" yaml:"version"`
 	ToolsVersion  string        `json:"tools_version" xml:"tools_version" yaml:"tools_version"`
 	Description   string        `json:"description" xml:"description" yaml:"description"`
 	Documentation string        `json:"documentation" xml:"documentation" yaml:"documentation"`
 	Git           gitMetadata   `json:"git" xml:"git" yaml:"git"`
 	Build         buildMetadata `json:"build" xml:"build" yaml:"build"`
+	// Dependencies are the modules the binary is built with, see
+	// DependenciesFromBuildInfo.
+	Dependencies []*moduleDependency `json:"dependencies,omitempty" xml:"dependencies>dependency,omitempty" yaml:"dependencies,omitempty"`
+	// linked holds the attributes set with -ldflags, i.e. the non-default
+	// values of the Set* calls.
+	linked map[string]bool
 }
 
 // NewPackageManager return an instance of PackageManager.
@@ -47,8 +54,11 @@ func NewPackageManager(s string) *PackageManager {
 
 // gitMetadata stores Git-related metadata.
 type gitMetadata struct {
-	Branch string `json:"branch" xml:"branch" yaml:"branch"`
-	Commit string `json:"commit" xml:"commit" yaml:"commit"`
+	Branch     string `json:"branch" xml:"branch" yaml:"branch"`
+	Commit     string `json:"commit" xml:"commit" yaml:"commit"`
+	Tag        string `json:"tag" xml:"tag" yaml:"tag"`
+	CommitDate string `json:"commit_date" xml:"commit_date" yaml:"commit_date"`
+	Dirty      bool   `json:"dirty" xml:"dirty" yaml:"dirty"`
 }
 
 // buildInfo stores build-related metadata.
@@ -57,6 +67,16 @@ type buildMetadata struct {
 	Architecture    string `json:"arch" xml:"arch" yaml:"arch"`
 	User            string `json:"user" xml:"user" yaml:"user"`
 	Date            string `json:"date" xml:"date" yaml:"date"`
+	Host            string `json:"host" xml:"host" yaml:"host"`
+	GoVersion       string `json:"go_version" xml:"go_version" yaml:"go_version"`
+	CGO             bool   `json:"cgo" xml:"cgo" yaml:"cgo"`
+}
+
+// moduleDependency stores the path and the version of a module.
+type moduleDependency struct {
+	Path    string `json:"path" xml:"path" yaml:"path"`
+	Version string `json:"version" xml:"version" yaml:"version"`
+	Replace string `json:"replace,omitempty" xml:"replace,omitempty" yaml:"replace,omitempty"`
 }
 
 // Banner returns package
@@ -66,19 +86,37 @@ func (p *PackageManager) Banner() string {
 	if p.Git.Branch != "" {
 		sb.WriteString(fmt.Sprintf(", branch: %s", p.Git.Branch))
 	}
+	if p.Git.Tag != "" {
+		sb.WriteString(fmt.Sprintf(", tag: %s", p.Git.Tag))
+	}
 	if p.Git.Commit != "" {
 		sb.WriteString(fmt.Sprintf(", commit: %s", p.Git.Commit))
+		if p.Git.CommitDate != "" {
+			sb.WriteString(fmt.Sprintf(" on %s", p.Git.CommitDate))
+		}
+	}
+	if p.Git.Dirty {
+		sb.WriteString(", dirty")
 	}
 	if p.Build.User != "" && p.Build.Date != "" {
 		sb.WriteString(fmt.Sprintf(", build on %s by %s",
 			p.Build.Date, p.Build.User,
 		))
+		if p.Build.Host != "" {
+			sb.WriteString(fmt.Sprintf(" at %s", p.Build.Host))
+		}
 		if p.Build.OperatingSystem != "" && p.Build.Architecture != "" {
 			sb.WriteString(
 				fmt.Sprintf(" for %s/%s",
 					p.Build.OperatingSystem, p.Build.Architecture,
 				))
 		}
+		if p.Build.GoVersion != "" {
+			sb.WriteString(fmt.Sprintf(" with %s", p.Build.GoVersion))
+		}
+		if p.Build.CGO {
+			sb.WriteString(", cgo enabled")
+		}
 		sb.WriteString(fmt.Sprintf(
 			" (%s/%s %s versioned %s)",
 			runtime.GOOS,
@@ -102,6 +140,7 @@ func (p *PackageManager) SetVersion(v, d string) {
 	p.ToolsVersion = d
 	if v != "" {
 		p.Version = v
+		p.setLinked("version")
 		return
 	}
 	p.Version = d
@@ -111,6 +150,7 @@ func (p *PackageManager) SetVersion(v, d string) {
 func (p *PackageManager) SetGitBranch(v, d string) {
 	if v != "" {
 		p.Git.Branch = v
+		p.setLinked("branch")
 		return
 	}
 	p.Git.Branch = d
@@ -120,6 +160,7 @@ func (p *PackageManager) SetGitBranch(v, d string) {
 func (p *PackageManager) SetGitCommit(v, d string) {
 	if v != "" {
 		p.Git.Commit = v
+		p.setLinked("commit")
 		return
 	}
 	p.Git.Commit = d
@@ -129,6 +170,7 @@ func (p *PackageManager) SetGitCommit(v, d string) {
 func (p *PackageManager) SetBuildUser(v, d string) {
 	if v != "" {
 		p.Build.User = v
+		p.setLinked("user")
 		return
 	}
 	p.Build.User = d
@@ -138,11 +180,82 @@ func (p *PackageManager) SetBuildUser(v, d string) {
 func (p *PackageManager) SetBuildDate(v, d string) {
 	if v != "" {
 		p.Build.Date = v
+		p.setLinked("date")
 		return
 	}
 	p.Build.Date = d
 }
 
+// SetGitTag sets Git.Tag attribute of PackageManager.
+func (p *PackageManager) SetGitTag(v, d string) {
+	if v != "" {
+		p.Git.Tag = v
+		p.setLinked("tag")
+		return
+	}
+	p.Git.Tag = d
+}
+
+// SetGitCommitDate sets Git.CommitDate attribute of PackageManager.
+func (p *PackageManager) SetGitCommitDate(v, d string) {
+	if v != "" {
+		p.Git.CommitDate = v
+		p.setLinked("commit_date")
+		return
+	}
+	p.Git.CommitDate = d
+}
+
+// SetGitDirty sets Git.Dirty attribute of PackageManager. The values are
+// parsed with strconv.ParseBool, e.g. "true" or "1".
+func (p *PackageManager) SetGitDirty(v, d string) {
+	if v != "" {
+		p.Git.Dirty, _ = strconv.ParseBool(v)
+		p.setLinked("dirty")
+		return
+	}
+	p.Git.Dirty, _ = strconv.ParseBool(d)
+}
+
+// SetBuildHost sets Build.Host attribute of PackageManager.
+func (p *PackageManager) SetBuildHost(v, d string) {
+	if v != "" {
+		p.Build.Host = v
+		p.setLinked("host")
+		return
+	}
+	p.Build.Host = d
+}
+
+// SetBuildGoVersion sets Build.GoVersion attribute of PackageManager.
+func (p *PackageManager) SetBuildGoVersion(v, d string) {
+	if v != "" {
+		p.Build.GoVersion = v
+		p.setLinked("go_version")
+		return
+	}
+	p.Build.GoVersion = d
+}
+
+// SetBuildCGO sets Build.CGO attribute of PackageManager. The values are
+// parsed with strconv.ParseBool, e.g. "true" or "1".
+func (p *PackageManager) SetBuildCGO(v, d string) {
+	if v != "" {
+		p.Build.CGO, _ = strconv.ParseBool(v)
+		p.setLinked("cgo")
+		return
+	}
+	p.Build.CGO, _ = strconv.ParseBool(d)
+}
+
+// setLinked marks the attribute as set with -ldflags.
+func (p *PackageManager) setLinked(s string) {
+	if p.linked == nil {
+		p.linked = make(map[string]bool)
+	}
+	p.linked[s] = true
+}
+
 func (p *PackageManager) String() string {
 	return p.Banner()
 }
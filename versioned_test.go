@@ -323,4 +323,17 @@ func TestAppPackage(t *testing.T) {
 	app.Build.OperatingSystem = "myos"
 	app.Build.Architecture = "amd64"
 	t.Logf("%s", app)
+
+	app.SetGitTag("v1.0.1", "")
+	app.SetGitCommitDate("", "2020-04-24")
+	app.SetGitDirty("true", "")
+	app.SetBuildHost("", "ci.local")
+	app.SetBuildGoVersion("go1.14.2", "")
+	app.SetBuildCGO("", "1")
+	vers = strings.Split(app.Banner(), " (")[0]
+	expVers = "versioned 1.0.1, branch: master, tag: v1.0.1, commit: v1.0.1-g0c85fbc on 2020-04-24, dirty, " +
+		"build on 2020-04-25 by greenpau at ci.local for myos/amd64 with go1.14.2, cgo enabled"
+	if vers != expVers {
+		t.Fatalf("FAIL: Version mismatch: %s (expected) vs. %s (received)", expVers, vers)
+	}
 }